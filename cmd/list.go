@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/registry"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List installed release binaries",
+	Long:  `List repository releases installed by gh install, with installed tag, asset and files.`,
+	Args:  cobra.NoArgs,
+	RunE:  runList,
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	installRegistry, err := registry.Load()
+	if err != nil {
+		return err
+	}
+
+	for _, repo := range installRegistry.Repositories() {
+		entry, _ := installRegistry.Get(repo)
+		output.Output().Set(repo, entry)
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/maratoid/gh-install/manifest"
+	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/release"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lockCmd = &cobra.Command{
+		Use:   "lock",
+		Short: "Resolve manifest file releases into a lock file",
+		Long: `Resolve every tool of a manifest file to a concrete release tag, release id, asset name and asset sha256
+			for the platform of the tool ('os', 'arch' and 'libc' fields, the host otherwise) and write them to
+			the lock file next to the manifest ('gh-install.lock' for 'gh-install.yaml').
+			Use 'gh install sync --frozen' to install exactly the locked assets.`,
+		Args: cobra.NoArgs,
+		RunE: runLock,
+	}
+	lockManifestPath string
+)
+
+type lockResult struct {
+	release.Pin
+}
+
+func (l lockResult) String() string {
+	return fmt.Sprintf("%s %s for %s (sha256 %s)", l.Tag, l.Asset, l.Platform, l.Sha256)
+}
+
+func runLock(cmd *cobra.Command, args []string) error {
+	toolManifest, err := manifest.Load(lockManifestPath)
+	if err != nil {
+		return err
+	}
+
+	lock := &manifest.Lock{}
+	for _, tool := range toolManifest.Tools {
+		pin, err := release.MakeGithubRelease(withSpecDefaults(tool), ghClient, false).Resolve()
+		if err != nil {
+			return fmt.Errorf("could not resolve %s: %v", tool.Repository, err)
+		}
+		lock.Tools = append(lock.Tools, *pin)
+	}
+
+	lockPath := manifest.LockPath(lockManifestPath)
+	if err = lock.Save(lockPath); err != nil {
+		return err
+	}
+
+	// release resolution output is replaced with the locked assets
+	output.Output().Reset()
+	output.Output().Set("lock_file", lockPath)
+	for _, pin := range lock.Tools {
+		output.Output().Set(pin.Repository, lockResult{pin})
+	}
+
+	return nil
+}
+
+func init() {
+	lockCmd.Flags().StringVarP(&lockManifestPath, "file", "f", manifest.GH_INSTALL_MANIFEST_FILE,
+		"Manifest file listing repositories to lock.")
+	rootCmd.AddCommand(lockCmd)
+}
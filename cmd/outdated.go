@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/registry"
+	"github.com/spf13/cobra"
+)
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated [owner/repository...]",
+	Short: "Report installed release binaries with newer releases available",
+	Long: fmt.Sprintf(`Check installed Github repository releases against their latest release without installing anything.
+			Exits with code %d if any repository is outdated.`, GH_INSTALL_EXIT_OUTDATED),
+	RunE: runOutdated,
+}
+
+type outdatedResult struct {
+	InstalledTag string `json:"installed_tag"`
+	LatestTag    string `json:"latest_tag,omitempty"`
+	PublishedAt  string `json:"published_at,omitempty"`
+	Outdated     bool   `json:"outdated"`
+	Ahead        bool   `json:"ahead,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+func (o outdatedResult) String() string {
+	if o.Error != "" {
+		return fmt.Sprintf("%s check failed: %s", o.InstalledTag, o.Error)
+	}
+	if o.Outdated {
+		return fmt.Sprintf("%s → %s (published %s)", o.InstalledTag, o.LatestTag, o.PublishedAt)
+	}
+	if o.Ahead {
+		return fmt.Sprintf("%s (newer than latest %s)", o.InstalledTag, o.LatestTag)
+	}
+	return fmt.Sprintf("%s (up to date)", o.InstalledTag)
+}
+
+func runOutdated(cmd *cobra.Command, args []string) error {
+	installRegistry, err := registry.Load()
+	if err != nil {
+		return err
+	}
+
+	repos := args
+	if len(repos) == 0 {
+		repos = installRegistry.Repositories()
+	}
+
+	results := make(map[string]outdatedResult)
+	for _, repo := range repos {
+		entry, found := installRegistry.Get(repo)
+		if !found {
+			return fmt.Errorf("repository %s was not installed with gh install", repo)
+		}
+
+		result := outdatedResult{InstalledTag: entry.Tag}
+		spec := entry.Spec
+		spec.ReleaseVersion, spec.ReleasePattern = GH_INSTALL_VERSION_LATEST, ""
+
+		latest, err := resolveRelease(spec)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.LatestTag = latest.Name
+			result.PublishedAt = latest.GetPropStr("publishedAt")
+			// installed tags that are not semantic versions are outdated by any other latest tag
+			compared, ordered := compareTags(entry.Tag, latest.Name)
+			result.Outdated = !ordered || compared < 0
+			result.Ahead = ordered && compared > 0
+		}
+		results[entry.Spec.Repository] = result
+	}
+
+	// release resolution output is replaced with the report
+	output.Output().Reset()
+	for repo, result := range results {
+		output.Output().Set(repo, result)
+		if result.Error != "" {
+			exitCode = 1
+		} else if result.Outdated && exitCode == 0 {
+			exitCode = GH_INSTALL_EXIT_OUTDATED
+		}
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(outdatedCmd)
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/registry"
+	"github.com/maratoid/gh-install/release"
+	"github.com/maratoid/gh-install/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneCmd = &cobra.Command{
+		Use:   "prune [owner/repository...]",
+		Short: "Delete inactive release versions from the store",
+		Long: `Delete release versions kept in the store by '--store' installations that are not active,
+			keeping the '--keep' most recently installed inactive versions of each repository.
+			All installed repositories are pruned if none are specified.`,
+		Args: validatePruneArgs,
+		RunE: runPrune,
+	}
+	pruneKeep   int
+	pruneDryRun bool
+	// owner and repository names, repositories are also store directory names
+	repositoryRE = regexp.MustCompile(`^[A-Za-z0-9-]+/[A-Za-z0-9_.-]+$`)
+)
+
+type pruneResult struct {
+	ActiveTag   string   `json:"active_tag,omitempty"`
+	KeptTags    []string `json:"kept_tags"`
+	RemovedTags []string `json:"removed_tags"`
+	Error       string   `json:"error,omitempty"`
+}
+
+func (p pruneResult) String() string {
+	if p.Error != "" {
+		return fmt.Sprintf("failed: %s", p.Error)
+	}
+	return fmt.Sprintf("active %s, kept [%s], removed [%s]", p.ActiveTag,
+		strings.Join(p.KeptTags, ", "), strings.Join(p.RemovedTags, ", "))
+}
+
+// reports whether repo is a 'user/repository' name that is safe to use as a store directory
+func isRepository(repo string) bool {
+	name := path.Base(repo)
+	return repositoryRE.MatchString(repo) && name != "." && name != ".."
+}
+
+func validatePruneArgs(cmd *cobra.Command, args []string) error {
+	for _, repo := range args {
+		if !isRepository(repo) {
+			return fmt.Errorf("repository must be in 'user/repository' format (provided: %s)", repo)
+		}
+	}
+	return nil
+}
+
+// inactive store versions of repo, most recently installed first
+func inactiveVersions(repo string, activeTag string) ([]string, error) {
+	tags, err := store.Versions(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	installedAt := make(map[string]time.Time)
+	var inactive []string
+	for _, tag := range tags {
+		if tag == activeTag {
+			continue
+		}
+		if receipt, err := release.LoadStoreReceipt(repo, tag); err == nil {
+			installedAt[tag] = receipt.InstalledAt
+		}
+		inactive = append(inactive, tag)
+	}
+	sort.SliceStable(inactive, func(i, j int) bool {
+		return installedAt[inactive[i]].After(installedAt[inactive[j]])
+	})
+
+	return inactive, nil
+}
+
+func pruneRepo(installRegistry *registry.Registry, repo string) pruneResult {
+	result := pruneResult{KeptTags: make([]string, 0), RemovedTags: make([]string, 0)}
+	if entry, found := installRegistry.Get(repo); found && entry.Spec.Store {
+		result.ActiveTag = entry.Tag
+	}
+
+	inactive, err := inactiveVersions(repo, result.ActiveTag)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	for index, tag := range inactive {
+		if index < pruneKeep {
+			result.KeptTags = append(result.KeptTags, tag)
+			continue
+		}
+		if !pruneDryRun {
+			if err = store.RemoveVersion(repo, tag); err != nil {
+				result.Error = err.Error()
+				return result
+			}
+		}
+		result.RemovedTags = append(result.RemovedTags, tag)
+	}
+
+	return result
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	if pruneKeep < 0 {
+		return fmt.Errorf("'--keep' can not be negative")
+	}
+
+	installRegistry, err := registry.Load()
+	if err != nil {
+		return err
+	}
+
+	repos := args
+	if len(repos) == 0 {
+		repos = installRegistry.Repositories()
+	}
+	for _, repo := range repos {
+		if _, found := installRegistry.Get(repo); !found {
+			return fmt.Errorf("repository %s was not installed with gh install", repo)
+		}
+	}
+
+	output.Output().Reset()
+	output.Output().Set("dry_run", pruneDryRun)
+	for _, repo := range repos {
+		result := pruneRepo(installRegistry, repo)
+		if result.Error != "" {
+			exitCode = 1
+		}
+		output.Output().Set(repo, result)
+	}
+
+	return nil
+}
+
+func init() {
+	pruneCmd.Flags().IntVarP(&pruneKeep, "keep", "k", 1,
+		"Number of most recently installed inactive versions to keep for each repository.")
+	pruneCmd.Flags().BoolVarP(&pruneDryRun, "dry-run", "n", false,
+		"Report versions that would be deleted without deleting them.")
+	rootCmd.AddCommand(pruneCmd)
+}
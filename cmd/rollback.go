@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/registry"
+	"github.com/maratoid/gh-install/release"
+	"github.com/maratoid/gh-install/store"
+	"github.com/spf13/cobra"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback owner/repository",
+	Short: "Restore the previously installed release of a Github repository",
+	Long: `Restore the release installed before the last installation, upgrade or sync of a Github repository.
+			Files kept from the previous installation are restored without network access; if they were not
+			kept, the exact release asset of the previous installation is downloaded again.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRollback,
+}
+
+// removes unmodified files of the replaced installation that are not part of the restored one. Store versions
+// are kept
+func removeStaleFiles(replaced *release.Receipt, restored *release.Receipt) []string {
+	restoredPaths := make(map[string]bool)
+	for _, file := range restored.Files {
+		restoredPaths[file.Path] = true
+	}
+
+	removedFiles := make([]string, 0)
+	storeDir := store.RepoDir(replaced.Spec.Repository) + "/"
+	for _, file := range replaced.Files {
+		if restoredPaths[file.Path] || strings.HasPrefix(file.Path, storeDir) {
+			continue
+		}
+		if modified, err := file.Modified(); err != nil || modified {
+			continue
+		}
+		if os.Remove(file.Path) == nil {
+			removedFiles = append(removedFiles, file.Path)
+		}
+	}
+	return removedFiles
+}
+
+// restores previous installation from kept files and keeps the files of the current one, so that rollback
+// can be undone by another rollback
+func restoreKept(installRegistry *registry.Registry, entry *registry.Entry) (*registry.Entry, error) {
+	current, err := installRegistry.Keep(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := entry.Previous
+	if err = release.RestoreFiles(&previous.Receipt, previous.Kept); err != nil {
+		current.Discard()
+		return nil, err
+	}
+
+	restored := installRegistry.Record(&previous.Receipt)
+	restored.Previous = current
+	previous.Discard()
+
+	return restored, installRegistry.Save()
+}
+
+func inStore(repo string, tag string) bool {
+	_, err := release.LoadStoreReceipt(repo, tag)
+	return err == nil
+}
+
+// reinstalls exact release asset of the previous installation
+func reinstallPrevious(previous *registry.Previous) (*registry.Entry, error) {
+	if previous.AssetSha256 == "" {
+		spec := previous.Spec
+		spec.ReleaseVersion, spec.ReleasePattern = previous.Tag, ""
+		spec.AssetName, spec.AssetPattern = previous.Asset, ""
+		return installAndRecord(spec.Repository, release.MakeGithubRelease(spec, ghClient, false))
+	}
+
+	pin := &release.Pin{
+		Repository: previous.Spec.Repository,
+		Tag:        previous.Tag,
+		ReleaseId:  previous.ReleaseId,
+		Asset:      previous.Asset,
+		Sha256:     previous.AssetSha256,
+	}
+	return installAndRecord(pin.Repository, release.MakePinnedGithubRelease(previous.Spec, pin, ghClient))
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	installRegistry, err := registry.Load()
+	if err != nil {
+		return err
+	}
+
+	entry, found := installRegistry.Get(args[0])
+	if !found {
+		return fmt.Errorf("repository %s was not installed with gh install", args[0])
+	}
+	output.Output().Set("target_repository", entry.Spec.Repository)
+	output.Output().Set("installed_tag", entry.Tag)
+	if entry.Previous == nil {
+		return fmt.Errorf("no previous installation of %s to roll back to", entry.Spec.Repository)
+	}
+
+	replaced := entry.Receipt
+	var restored *registry.Entry
+	if entry.Previous.Spec.Store && inStore(entry.Spec.Repository, entry.Previous.Tag) {
+		output.Output().Set("restored_from", "store")
+		restored, err = activateStoreVersion(installRegistry, entry.Spec.Repository, entry.Previous.Tag)
+	} else if entry.Previous.Complete() {
+		output.Output().Set("restored_from", "kept files")
+		restored, err = restoreKept(installRegistry, entry)
+	} else {
+		output.Output().Set("restored_from", "release asset")
+		restored, err = reinstallPrevious(entry.Previous)
+	}
+	if err != nil {
+		return err
+	}
+
+	output.Output().Set("restored_tag", restored.Tag)
+	output.Output().Set("removed_files", removeStaleFiles(&replaced, &restored.Receipt))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+}
@@ -5,44 +5,66 @@ import (
 	"os"
 	"path"
 	"regexp"
-	"runtime"
 	"strings"
 
 	"github.com/cli/go-gh/v2/pkg/api"
 	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/platform"
+	"github.com/maratoid/gh-install/registry"
 	"github.com/maratoid/gh-install/release"
+	"github.com/maratoid/gh-install/selector"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 const (
 	GH_INSTALL_VERSION_LATEST       = "latest"
+	GH_INSTALL_EXIT_OUTDATED        = 2
 	GH_INSTALL_ENV_PATH             = "GH_INSTALL_PATH"
-	GH_INSTALL_CHECKSUM_ASSET_REGEX = ".*(?:checksum|txt)+.*$"
+	GH_INSTALL_CHECKSUM_ASSET_REGEX = `(?i)^.*(?:checksum|sha(?:1|256|512)sum|\.sha(?:256|512)$|\.txt$).*$`
 )
 
 var (
 	rootCmd = &cobra.Command{
-		Use:   "gh install owner/repository",
+		Use:   "install owner/repository",
 		Short: "Install github release binaries",
 		Long: `Install binaries for a Github repository release interactively or non-interactively.
 			Intended for quickly installing release binaries for projects that do not distribute
 			using Homebrew or other package managers.`,
-		Args:    validateRepositoryArg,
-		RunE:    runInstall,
-		Version: "1.1.2",
-	}
-	targetRepo, releaseVersion, releaseInstallPath           string
-	downloadPattern, binaryPattern, binaryName, downloadName string
-	interactive, jsonOut, noCreatePath                       bool
-	ghClient                                                 *api.RESTClient
+		Args:             validateRepositoryArg,
+		PersistentPreRun: silenceOnJson,
+		RunE:             runInstall,
+		Version:          "1.1.2",
+		Annotations: map[string]string{
+			cobra.CommandDisplayNameAnnotation: "gh install",
+		},
+	}
+	targetRepo, releaseVersion, releasePattern, releaseInstallPath string
+	downloadPattern, binaryPattern, downloadName                   string
+	checksumPattern, releaseChannel                                string
+	targetOS, targetArch, targetLibc, installName                  string
+	interactive, jsonOut, noCreatePath                             bool
+	requireChecksum, skipChecksum, forceInstall, strictInstall     bool
+	storeInstall, multipleBinaries, allBinaries, noExtras          bool
+	generateCompletions, libexecInstall                            bool
+	binaryNames, includeRules                                      []string
+	binaryMode                                                     string
+	allowPrerelease, includeDrafts                                 bool
+	ghClient                                                       *api.RESTClient
+	// exit code of commands that completed with output but did not fully succeed
+	exitCode int
 )
 
-func validateRepositoryArg(cmd *cobra.Command, args []string) error {
+// errors are reported as part of JSON output
+func silenceOnJson(cmd *cobra.Command, args []string) {
 	if viper.GetBool("json") && !viper.GetBool("interactive") {
 		cmd.SilenceUsage = true
 		cmd.SilenceErrors = true
 	}
+}
+
+func validateRepositoryArg(cmd *cobra.Command, args []string) error {
+	silenceOnJson(cmd, args)
 
 	if len(args) != 1 {
 		return fmt.Errorf("accepts %d arg(s), received %d", 1, len(args))
@@ -61,7 +83,7 @@ func validateRepositoryArg(cmd *cobra.Command, args []string) error {
 
 	targetRepo = args[0]
 	if viper.GetString("binary-regex") == "" {
-		viper.Set("binary-regex", fmt.Sprintf("^%s$", strings.Split(targetRepo, "/")[1]))
+		viper.Set("binary-regex", defaultBinaryPattern(targetRepo))
 	}
 
 	return nil
@@ -92,17 +114,120 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	installRelease := release.MakeGithubRelease(
-		targetRepo,
-		viper.GetString("tag"),
-		viper.GetString("path"),
-		viper.GetString("download"),
-		viper.GetString("download-regex"),
-		viper.GetString("binary"),
-		viper.GetString("binary-regex"),
-		ghClient,
-		viper.GetBool("interactive"))
-	return installRelease.Install()
+	if err := (selector.ReleasePolicy{Channel: viper.GetString("channel")}).Validate(); err != nil {
+		return err
+	}
+
+	if viper.GetBool("require-checksum") && viper.GetBool("skip-checksum") {
+		return fmt.Errorf("'--require-checksum' and '--skip-checksum' are mutually exclusive")
+	}
+
+	osName, err := platform.ParseOS(viper.GetString("os"))
+	if err != nil {
+		return err
+	}
+	archName, err := platform.ParseArch(viper.GetString("arch"))
+	if err != nil {
+		return err
+	}
+	libc, err := platform.ParseLibc(viper.GetString("libc"))
+	if err != nil {
+		return err
+	}
+
+	_, err = installAndRecord(targetRepo, release.MakeGithubRelease(release.Spec{
+		Repository:         targetRepo,
+		ReleaseVersion:     viper.GetString("tag"),
+		ReleasePattern:     viper.GetString("tag-regex"),
+		Channel:            viper.GetString("channel"),
+		Prerelease:         viper.GetBool("prerelease"),
+		IncludeDrafts:      viper.GetBool("include-drafts"),
+		InstallPath:        viper.GetString("path"),
+		AssetName:          viper.GetString("download"),
+		AssetPattern:       viper.GetString("download-regex"),
+		AssetBinaryNames:   viper.GetStringSlice("binary"),
+		AssetBinaryPattern: viper.GetString("binary-regex"),
+		ChecksumPattern:    viper.GetString("checksum-regex"),
+		RequireChecksum:    viper.GetBool("require-checksum"),
+		SkipChecksum:       viper.GetBool("skip-checksum"),
+		OS:                 osName,
+		Arch:               archName,
+		Libc:               libc,
+		Force:              viper.GetBool("force"),
+		Strict:             viper.GetBool("strict"),
+		Store:              viper.GetBool("store"),
+		InstallName:        viper.GetString("as"),
+		MultipleBinaries:   viper.GetBool("multiple"),
+		AllBinaries:        viper.GetBool("all-binaries"),
+		NoExtras:           viper.GetBool("no-extras"),
+		Completions:        viper.GetBool("completions"),
+		Includes:           viper.GetStringSlice("include"),
+		Libexec:            viper.GetBool("libexec"),
+		Mode:               viper.GetString("mode"),
+	}, ghClient, viper.GetBool("interactive")))
+	return err
+}
+
+func defaultBinaryPattern(repo string) string {
+	return fmt.Sprintf("^%s$", strings.Split(repo, "/")[1])
+}
+
+// fills fields not set in spec with installation flag defaults
+func withSpecDefaults(spec release.Spec) release.Spec {
+	if spec.ReleaseVersion == "" {
+		spec.ReleaseVersion = viper.GetString("tag")
+	}
+	if spec.InstallPath == "" {
+		spec.InstallPath = viper.GetString("path")
+	}
+	if spec.AssetName == "" && spec.AssetPattern == "" {
+		spec.AssetPattern = viper.GetString("download-regex")
+	}
+	if len(spec.AssetBinaryNames) == 0 && spec.AssetBinaryPattern == "" && !spec.AllBinaries {
+		spec.AssetBinaryPattern = defaultBinaryPattern(spec.Repository)
+	}
+	if spec.ChecksumPattern == "" {
+		spec.ChecksumPattern = viper.GetString("checksum-regex")
+	}
+	return spec
+}
+
+// installs release of repo and records the installation in the registry. Files of the installation it replaces
+// are kept so that it can be rolled back
+func installAndRecord(repo string, installRelease release.IRelease) (*registry.Entry, error) {
+	installRegistry, err := registry.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var previous *registry.Previous
+	current, installed := installRegistry.Get(repo)
+	if installed {
+		previous, err = installRegistry.Keep(current)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = installRelease.Install()
+	if err != nil {
+		previous.Discard()
+		return nil, err
+	}
+
+	entry := installRegistry.Record(installRelease.Receipt())
+	switch {
+	case !installed:
+	case current.Tag == entry.Tag && current.Previous != nil:
+		// reinstalling the same release keeps the installation it replaced
+		previous.Discard()
+		entry.Previous = current.Previous
+	default:
+		current.Previous.Discard()
+		entry.Previous = previous
+	}
+
+	return entry, installRegistry.Save()
 }
 
 func Execute() {
@@ -122,7 +247,7 @@ func Execute() {
 		}
 
 		printOutput(err)
-		return 0
+		return exitCode
 	}())
 }
 
@@ -147,18 +272,59 @@ func getDefaultInstallPath() string {
 
 func init() {
 	viper.SetEnvPrefix("gh_install")
-	rootCmd.Flags().StringVarP(&binaryName, "binary", "b", "",
-		"install release asset archive binary name. If empty, '--binary-regex' is used.")
+	rootCmd.Flags().StringVarP(&installName, "as", "", "",
+		"installed binary name, or name template such as '{{.Repo}}' or '{{.Binary | trimPlatform}}'. If empty, bare binary assets are installed without version and platform suffixes.")
+	rootCmd.Flags().StringSliceVarP(&binaryNames, "binary", "b", nil,
+		"install release asset archive binary name, repeatable. If empty, '--binary-regex' is used.")
+	rootCmd.Flags().StringVarP(&binaryMode, "mode", "", "",
+		"octal permission bits of installed binaries, e.g. '0750'. If empty, archived binary mode is kept.")
 	rootCmd.Flags().StringVarP(&binaryPattern, "binary-regex", "", "",
 		"lookup regexp for release asset archive binary. If empty, repository name is used.")
+	rootCmd.Flags().BoolVarP(&multipleBinaries, "multiple", "", false,
+		"install every release asset archive binary matching '--binary-regex' instead of failing on more than one match.")
+	rootCmd.Flags().BoolVarP(&allBinaries, "all-binaries", "", false,
+		"install every executable release asset archive binary.")
 	rootCmd.Flags().StringVarP(&releaseVersion, "tag", "t", GH_INSTALL_VERSION_LATEST,
-		"release tag (version) to install.")
+		"release tag (version) to install: exact tag, 'latest' or semver constraint such as '~1.2', '^2', '>=1.4 <2', '1.x'.")
+	rootCmd.Flags().StringVarP(&releasePattern, "tag-regex", "", "",
+		"lookup regexp for release tag to install. If not empty, '--tag' is ignored.")
+	rootCmd.Flags().BoolVarP(&allowPrerelease, "prerelease", "", false,
+		"allow pre-releases: 'latest' and semver constraints select the newest stable or pre-release.")
+	rootCmd.Flags().StringVarP(&releaseChannel, "channel", "", selector.GH_INSTALL_CHANNEL_STABLE,
+		"release channel 'latest' and semver constraints select from: 'stable', 'prerelease' or 'any'.")
+	rootCmd.Flags().BoolVarP(&includeDrafts, "include-drafts", "", false,
+		"include draft releases (visible to authenticated users with push access only).")
 	rootCmd.Flags().StringVarP(&downloadName, "download", "d", "",
 		"name for release asset to download. If empty, '--download-regex' is used.")
-	rootCmd.Flags().StringVarP(&downloadPattern, "download-regex", "",
-		fmt.Sprintf("^.*(?:%s.+%s|%s.+%s)+.*$", runtime.GOARCH, runtime.GOOS, runtime.GOOS, runtime.GOARCH),
-		"lookup regexp for release asset to download.")
-	rootCmd.Flags().BoolVarP(&jsonOut, "json", "j", false,
+	rootCmd.Flags().StringVarP(&downloadPattern, "download-regex", "", "",
+		"lookup regexp for release asset to download. If empty, asset best matching host os and architecture is used.")
+	rootCmd.Flags().StringVarP(&checksumPattern, "checksum-regex", "", GH_INSTALL_CHECKSUM_ASSET_REGEX,
+		"lookup regexp for release checksum assets used to verify the downloaded asset.")
+	rootCmd.Flags().BoolVarP(&requireChecksum, "require-checksum", "", false,
+		"Fail installation if the downloaded asset can not be verified against a release checksum.")
+	rootCmd.Flags().BoolVarP(&skipChecksum, "skip-checksum", "", false,
+		"Do not verify the downloaded asset against release checksums.")
+	rootCmd.Flags().StringVarP(&targetOS, "os", "", "",
+		"operating system to download release assets for, e.g. 'linux', 'darwin', 'windows'. If empty, host os is used.")
+	rootCmd.Flags().StringVarP(&targetArch, "arch", "", "",
+		"architecture to download release assets for, e.g. 'amd64', 'arm64', 'arm'. If empty, host architecture is used.")
+	rootCmd.Flags().StringVarP(&targetLibc, "libc", "", "",
+		"C library of Linux assets to prefer: 'gnu' (or 'glibc') or 'musl'. If empty, host C library is detected.")
+	rootCmd.Flags().BoolVarP(&forceInstall, "force", "", false,
+		"Install binaries whose executable format or architecture does not match the target platform.")
+	rootCmd.Flags().BoolVarP(&generateCompletions, "completions", "", false,
+		"Generate shell completions by running 'completion bash|zsh|fish' subcommand of installed binaries.")
+	rootCmd.Flags().StringArrayVarP(&includeRules, "include", "", nil,
+		"install release asset archive files matching 'src-glob:dest-dir' rule, repeatable. Relative 'dest-dir' is relative to '--path' (store version directory with '--store').")
+	rootCmd.Flags().BoolVarP(&libexecInstall, "libexec", "", false,
+		"Extract archive directory tree of binaries to the versioned store and link binaries into '--path'. Implies '--store'.")
+	rootCmd.Flags().BoolVarP(&noExtras, "no-extras", "", false,
+		"Do not install shell completions, man pages and docs shipped in release archives.")
+	rootCmd.Flags().BoolVarP(&storeInstall, "store", "", false,
+		"Install binaries to a versioned store under the gh-install data directory and link them into '--path'.")
+	rootCmd.Flags().BoolVarP(&strictInstall, "strict", "", false,
+		"Fail installation if a Linux binary needs a newer glibc or shared libraries missing on the host.")
+	rootCmd.PersistentFlags().BoolVarP(&jsonOut, "json", "j", false,
 		"JSON output")
 	rootCmd.Flags().BoolVarP(&interactive, "interactive", "i", false,
 		"Use interactive installation. If true, all other flags are ignored")
@@ -167,5 +333,6 @@ func init() {
 	rootCmd.Flags().BoolVarP(&noCreatePath, "no-create", "", false,
 		"Do not create target installation directory if it does not exist.")
 	viper.BindPFlags(rootCmd.Flags())
+	viper.BindPFlags(rootCmd.PersistentFlags())
 	viper.AutomaticEnv()
 }
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/maratoid/gh-install/manifest"
+	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/registry"
+	"github.com/maratoid/gh-install/release"
+	"github.com/spf13/cobra"
+)
+
+const (
+	GH_INSTALL_SYNC_INSTALL   = "install"
+	GH_INSTALL_SYNC_UPGRADE   = "upgrade"
+	GH_INSTALL_SYNC_DOWNGRADE = "downgrade"
+	GH_INSTALL_SYNC_REINSTALL = "reinstall"
+	GH_INSTALL_SYNC_UNCHANGED = "unchanged"
+	GH_INSTALL_SYNC_PRUNE     = "prune"
+)
+
+var (
+	syncCmd = &cobra.Command{
+		Use:   "sync",
+		Short: "Install release binaries listed in a manifest file",
+		Long: fmt.Sprintf(`Converge installed Github repository releases to a manifest file ('%s' by default):
+			install missing repositories, upgrade or downgrade repositories installed at a different release
+			and optionally remove repositories not listed in the manifest.`, manifest.GH_INSTALL_MANIFEST_FILE),
+		Args: cobra.NoArgs,
+		RunE: runSync,
+	}
+	syncManifestPath                  string
+	syncDryRun, syncPrune, syncFrozen bool
+)
+
+type syncResult struct {
+	Action       string `json:"action"`
+	InstalledTag string `json:"installed_tag,omitempty"`
+	TargetTag    string `json:"target_tag,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+func (s syncResult) String() string {
+	var result string
+	switch s.Action {
+	case GH_INSTALL_SYNC_INSTALL:
+		result = fmt.Sprintf("%s %s", s.Action, s.TargetTag)
+	case GH_INSTALL_SYNC_UNCHANGED:
+		result = fmt.Sprintf("%s (%s)", s.InstalledTag, s.Action)
+	case GH_INSTALL_SYNC_REINSTALL:
+		result = fmt.Sprintf("%s %s", s.Action, s.InstalledTag)
+	case GH_INSTALL_SYNC_PRUNE:
+		result = fmt.Sprintf("%s %s", s.Action, s.InstalledTag)
+	default:
+		result = fmt.Sprintf("%s %s → %s", s.Action, s.InstalledTag, s.TargetTag)
+	}
+
+	if s.Error != "" {
+		result = fmt.Sprintf("%s failed: %s", result, s.Error)
+	}
+	return result
+}
+
+// determines the action needed to converge installed repository to spec, or to pin if it is not nil
+func planSync(installRegistry *registry.Registry, spec release.Spec, pin *release.Pin) syncResult {
+	var targetTag string
+	var targetId int
+	if pin != nil {
+		targetTag, targetId = pin.Tag, pin.ReleaseId
+	} else {
+		target, err := resolveRelease(spec)
+		if err != nil {
+			return syncResult{Action: GH_INSTALL_SYNC_INSTALL, Error: err.Error()}
+		}
+		targetTag, targetId = target.Name, target.GetPropInt("id")
+	}
+
+	result := syncResult{TargetTag: targetTag}
+	entry, found := installRegistry.Get(spec.Repository)
+	if !found {
+		result.Action = GH_INSTALL_SYNC_INSTALL
+		return result
+	}
+	result.InstalledTag = entry.Tag
+
+	// releases are ordered by semantic version, by creation if tags are not semantic versions
+	compared, ordered := compareTags(entry.Tag, targetTag)
+	if !ordered || (compared == 0 && entry.Tag != targetTag) {
+		compared = entry.ReleaseId - targetId
+	}
+	switch {
+	case entry.Tag == targetTag && (pin == nil || entry.AssetSha256 == pin.Sha256) && entry.Spec.InstallsLike(spec):
+		result.Action = GH_INSTALL_SYNC_UNCHANGED
+	case entry.Tag == targetTag:
+		// manifest fields changed, or locked asset differs from the installed one
+		result.Action = GH_INSTALL_SYNC_REINSTALL
+	case compared < 0:
+		result.Action = GH_INSTALL_SYNC_UPGRADE
+	default:
+		result.Action = GH_INSTALL_SYNC_DOWNGRADE
+	}
+
+	return result
+}
+
+func applySync(spec release.Spec, pin *release.Pin, result syncResult) syncResult {
+	if result.Error != "" || result.Action == GH_INSTALL_SYNC_UNCHANGED {
+		return result
+	}
+
+	if err := os.MkdirAll(spec.InstallPath, os.ModePerm); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	installRelease := release.MakeGithubRelease(spec, ghClient, false)
+	if pin != nil {
+		installRelease = release.MakePinnedGithubRelease(spec, pin, ghClient)
+	}
+	if _, err := installAndRecord(spec.Repository, installRelease); err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	toolManifest, err := manifest.Load(syncManifestPath)
+	if err != nil {
+		return err
+	}
+
+	installRegistry, err := registry.Load()
+	if err != nil {
+		return err
+	}
+
+	var lock *manifest.Lock
+	if syncFrozen {
+		if lock, err = manifest.LoadLock(manifest.LockPath(syncManifestPath)); err != nil {
+			return err
+		}
+	}
+
+	results := make(map[string]syncResult)
+	for _, tool := range toolManifest.Tools {
+		spec := withSpecDefaults(tool)
+
+		var pin *release.Pin
+		if lock != nil {
+			var found bool
+			if pin, found = lock.Find(spec.Repository); !found {
+				results[spec.Repository] = syncResult{
+					Action: GH_INSTALL_SYNC_INSTALL,
+					Error:  "repository is not locked, run 'gh install lock'",
+				}
+				continue
+			}
+		}
+
+		result := planSync(installRegistry, spec, pin)
+		if !syncDryRun {
+			result = applySync(spec, pin, result)
+		}
+		results[spec.Repository] = result
+	}
+
+	if syncPrune {
+		// installations above update the registry on disk
+		if installRegistry, err = registry.Load(); err != nil {
+			return err
+		}
+
+		for _, repo := range installRegistry.Repositories() {
+			if toolManifest.Lists(repo) {
+				continue
+			}
+
+			entry, _ := installRegistry.Get(repo)
+			result := syncResult{Action: GH_INSTALL_SYNC_PRUNE, InstalledTag: entry.Tag}
+			if _, _, err := uninstallEntry(installRegistry, entry, syncDryRun, false); err != nil {
+				result.Error = err.Error()
+			}
+			results[repo] = result
+		}
+	}
+
+	// installation output of individual repositories is replaced with sync report
+	output.Output().Reset()
+	output.Output().Set("dry_run", syncDryRun)
+	for repo, result := range results {
+		output.Output().Set(repo, result)
+		if result.Error != "" {
+			exitCode = 1
+		}
+	}
+
+	return nil
+}
+
+func init() {
+	syncCmd.Flags().StringVarP(&syncManifestPath, "file", "f", manifest.GH_INSTALL_MANIFEST_FILE,
+		"Manifest file listing repositories to install.")
+	syncCmd.Flags().BoolVarP(&syncDryRun, "dry-run", "n", false,
+		"Report actions needed to converge to the manifest without performing them.")
+	syncCmd.Flags().BoolVarP(&syncFrozen, "frozen", "", false,
+		"Install exactly the release assets of the manifest lock file, failing if asset contents no longer match.")
+	syncCmd.Flags().BoolVarP(&syncPrune, "prune", "", false,
+		"Uninstall repositories installed with gh install that are not listed in the manifest.")
+	rootCmd.AddCommand(syncCmd)
+}
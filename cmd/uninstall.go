@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/manifoldco/promptui"
+	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/registry"
+	"github.com/maratoid/gh-install/store"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+var (
+	uninstallCmd = &cobra.Command{
+		Use:   "uninstall owner/repository",
+		Short: "Remove files installed for a Github repository release",
+		Long: `Remove exactly the files recorded by an earlier gh install of a Github repository release.
+			Files modified since installation are not removed unless confirmed or '--force' is used.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runUninstall,
+	}
+	uninstallDryRun, uninstallForce bool
+)
+
+// asks user to confirm removal of a modified file, if stdin is a terminal
+func confirmRemoval(filePath string) bool {
+	if viper.GetBool("json") || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+
+	prompt := promptui.Prompt{
+		Label:     fmt.Sprintf("%s was modified since installation. Remove anyway", filePath),
+		IsConfirm: true,
+	}
+	_, err := prompt.Run()
+	return err == nil
+}
+
+// removes files recorded for registry entry and the entry itself. Returns files removed (or to be removed
+// if dryRun is set) and recorded files that no longer exist
+func uninstallEntry(installRegistry *registry.Registry, entry *registry.Entry,
+	dryRun bool, force bool) ([]string, []string, error) {
+	removeFiles := make([]string, 0, len(entry.Files))
+	missingFiles := make([]string, 0)
+	for _, file := range entry.Files {
+		modified, err := file.Modified()
+		if err != nil {
+			if os.IsNotExist(err) {
+				missingFiles = append(missingFiles, file.Path)
+				continue
+			}
+			return nil, missingFiles, err
+		}
+
+		if modified && !force && !confirmRemoval(file.Path) {
+			return nil, missingFiles, fmt.Errorf("%s was modified since installation, use '--force' to remove it anyway", file.Path)
+		}
+		removeFiles = append(removeFiles, file.Path)
+	}
+
+	if dryRun {
+		return removeFiles, missingFiles, nil
+	}
+
+	removedFiles := make([]string, 0, len(removeFiles))
+	for _, filePath := range removeFiles {
+		if err := os.Remove(filePath); err != nil {
+			return removedFiles, missingFiles, err
+		}
+		removedFiles = append(removedFiles, filePath)
+	}
+
+	if err := entry.Previous.Discard(); err != nil {
+		return removedFiles, missingFiles, err
+	}
+	if entry.Spec.Store {
+		if err := store.RemoveRepo(entry.Spec.Repository); err != nil {
+			return removedFiles, missingFiles, err
+		}
+	}
+	installRegistry.Remove(entry.Spec.Repository)
+	return removedFiles, missingFiles, installRegistry.Save()
+}
+
+func runUninstall(cmd *cobra.Command, args []string) error {
+	installRegistry, err := registry.Load()
+	if err != nil {
+		return err
+	}
+
+	entry, found := installRegistry.Get(args[0])
+	if !found {
+		return fmt.Errorf("repository %s was not installed with gh install", args[0])
+	}
+	output.Output().Set("target_repository", entry.Spec.Repository)
+	output.Output().Set("installed_tag", entry.Tag)
+	output.Output().Set("dry_run", uninstallDryRun)
+
+	removedFiles, missingFiles, err := uninstallEntry(installRegistry, entry, uninstallDryRun, uninstallForce)
+	output.Output().Set("missing_files", missingFiles)
+	if removedFiles != nil {
+		output.Output().Set("removed_files", removedFiles)
+	}
+
+	return err
+}
+
+func init() {
+	uninstallCmd.Flags().BoolVarP(&uninstallDryRun, "dry-run", "n", false,
+		"Report files that would be removed without removing them.")
+	uninstallCmd.Flags().BoolVarP(&uninstallForce, "force", "f", false,
+		"Remove files even if they were modified since installation.")
+	rootCmd.AddCommand(uninstallCmd)
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/registry"
+	"github.com/maratoid/gh-install/release"
+	"github.com/maratoid/gh-install/selector"
+	"github.com/maratoid/gh-install/semver"
+	"github.com/spf13/cobra"
+)
+
+var (
+	upgradeCmd = &cobra.Command{
+		Use:   "upgrade [owner/repository...]",
+		Short: "Upgrade installed release binaries to their latest release",
+		Long: `Reinstall Github repository releases installed by gh install at their latest release,
+			using the same asset and binary selection as the original installation.`,
+		Args: validateUpgradeArgs,
+		RunE: runUpgrade,
+	}
+	upgradeAll bool
+)
+
+type upgradeResult struct {
+	Status       string `json:"status"`
+	InstalledTag string `json:"installed_tag"`
+	LatestTag    string `json:"latest_tag,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+func (u upgradeResult) String() string {
+	switch u.Status {
+	case "upgraded":
+		return fmt.Sprintf("%s → %s", u.InstalledTag, u.LatestTag)
+	case "skipped":
+		return fmt.Sprintf("%s (up to date)", u.InstalledTag)
+	case "ahead":
+		return fmt.Sprintf("%s (newer than latest %s)", u.InstalledTag, u.LatestTag)
+	}
+	return fmt.Sprintf("%s failed: %s", u.InstalledTag, u.Error)
+}
+
+func validateUpgradeArgs(cmd *cobra.Command, args []string) error {
+	if upgradeAll && len(args) > 0 {
+		return fmt.Errorf("repositories can not be specified together with '--all'")
+	}
+	if !upgradeAll && len(args) == 0 {
+		return fmt.Errorf("specify repositories to upgrade or '--all'")
+	}
+	return nil
+}
+
+// resolves release of spec the same way installation does
+func resolveRelease(spec release.Spec) (*selector.SelectorItem, error) {
+	releaseSelector, err := selector.ReleaseSelector(ghClient, spec.Repository,
+		spec.ReleaseVersion, spec.ReleasePattern, spec.ReleasePolicy(), false)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := releaseSelector.SelectItems()
+	if err != nil {
+		return nil, err
+	}
+
+	return releases[0], nil
+}
+
+// orders release tags: -1, 0 or 1 if tag is older than, the same as or newer than other. Tags that are not both
+// semantic versions are only ordered if they are the same
+func compareTags(tag string, other string) (int, bool) {
+	if tag == other {
+		return 0, true
+	}
+	version, err := semver.Parse(tag)
+	if err != nil {
+		return 0, false
+	}
+	otherVersion, err := semver.Parse(other)
+	if err != nil {
+		return 0, false
+	}
+	return version.Compare(otherVersion), true
+}
+
+func upgradeEntry(entry *registry.Entry) upgradeResult {
+	result := upgradeResult{InstalledTag: entry.Tag}
+
+	spec := entry.Spec
+	spec.ReleaseVersion, spec.ReleasePattern = GH_INSTALL_VERSION_LATEST, ""
+
+	latest, err := resolveRelease(spec)
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+	result.LatestTag = latest.Name
+
+	// installed tags that are not semantic versions are upgraded to any other latest tag
+	if compared, ordered := compareTags(entry.Tag, latest.Name); ordered && compared >= 0 {
+		result.Status = "skipped"
+		if compared > 0 {
+			result.Status = "ahead"
+		}
+		return result
+	}
+
+	if _, err = installAndRecord(spec.Repository, release.MakeGithubRelease(spec, ghClient, false)); err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = "upgraded"
+	return result
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	installRegistry, err := registry.Load()
+	if err != nil {
+		return err
+	}
+
+	repos := args
+	if upgradeAll {
+		repos = installRegistry.Repositories()
+	}
+
+	var entries []*registry.Entry
+	for _, repo := range repos {
+		entry, found := installRegistry.Get(repo)
+		if !found {
+			return fmt.Errorf("repository %s was not installed with gh install", repo)
+		}
+		entries = append(entries, entry)
+	}
+
+	results := make(map[string]upgradeResult)
+	for _, entry := range entries {
+		results[entry.Spec.Repository] = upgradeEntry(entry)
+	}
+
+	// installation output of individual repositories is replaced with upgrade summary
+	output.Output().Reset()
+	for repo, result := range results {
+		output.Output().Set(repo, result)
+		if result.Status == "failed" {
+			exitCode = 1
+		}
+	}
+
+	return nil
+}
+
+func init() {
+	upgradeCmd.Flags().BoolVarP(&upgradeAll, "all", "a", false,
+		"Upgrade all installed repositories.")
+	rootCmd.AddCommand(upgradeCmd)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/registry"
+	"github.com/maratoid/gh-install/release"
+	"github.com/maratoid/gh-install/store"
+	"github.com/spf13/cobra"
+)
+
+var useCmd = &cobra.Command{
+	Use:   "use owner/repository@tag",
+	Short: "Switch the active version of a release installed to the store",
+	Long: `Point the installed symbolic links of a Github repository to another release version kept in
+			the store by '--store' installations. Does not download anything.`,
+	Args: validateUseArg,
+	RunE: runUse,
+}
+
+func validateUseArg(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("accepts %d arg(s), received %d", 1, len(args))
+	}
+
+	repo, tag, found := strings.Cut(args[0], "@")
+	// '.' and '..' tags are not escaped in store directory names
+	if !found || !isRepository(repo) || tag == "" || tag == "." || tag == ".." {
+		return fmt.Errorf("version must be in 'user/repository@tag' format (provided: %s)", args[0])
+	}
+	return nil
+}
+
+// activates store version tag of repo and records it in the registry, replacing the active installation
+func activateStoreVersion(installRegistry *registry.Registry, repo string, tag string) (*registry.Entry, error) {
+	receipt, err := release.LoadStoreReceipt(repo, tag)
+	if err != nil {
+		return nil, fmt.Errorf("%v, install it with 'gh install --store --tag %s %s'", err, tag, repo)
+	}
+
+	if err = release.ActivateStoreVersion(receipt); err != nil {
+		return nil, err
+	}
+
+	current, installed := installRegistry.Get(repo)
+	activated := installRegistry.Record(receipt)
+	if installed && current.Tag != receipt.Tag {
+		current.Previous.Discard()
+		activated.Previous = &registry.Previous{Receipt: current.Receipt}
+	} else if installed {
+		activated.Previous = current.Previous
+	}
+
+	return activated, installRegistry.Save()
+}
+
+func runUse(cmd *cobra.Command, args []string) error {
+	repo, tag, _ := strings.Cut(args[0], "@")
+	output.Output().Set("target_repository", repo)
+
+	installRegistry, err := registry.Load()
+	if err != nil {
+		return err
+	}
+	entry, installed := installRegistry.Get(repo)
+	if installed {
+		output.Output().Set("installed_tag", entry.Tag)
+	}
+
+	activated, err := activateStoreVersion(installRegistry, repo, tag)
+	if err != nil {
+		return err
+	}
+	output.Output().Set("active_tag", activated.Tag)
+	output.Output().Set("store_dir", store.VersionDir(repo, tag))
+	if installed {
+		output.Output().Set("removed_files", removeStaleFiles(&entry.Receipt, &activated.Receipt))
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(useCmd)
+}
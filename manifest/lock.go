@@ -0,0 +1,53 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/maratoid/gh-install/release"
+	"gopkg.in/yaml.v3"
+)
+
+// manifest tools resolved to concrete release assets
+type Lock struct {
+	Tools []release.Pin `yaml:"tools"`
+}
+
+// lock file path for manifest: 'gh-install.yaml' is locked in 'gh-install.lock'
+func LockPath(manifestPath string) string {
+	return strings.TrimSuffix(manifestPath, path.Ext(manifestPath)) + ".lock"
+}
+
+func LoadLock(lockPath string) (*Lock, error) {
+	content, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &Lock{}
+	if err = yaml.Unmarshal(content, lock); err != nil {
+		return nil, fmt.Errorf("could not parse lock file %s: %v", lockPath, err)
+	}
+
+	return lock, nil
+}
+
+func (l *Lock) Save(lockPath string) error {
+	content, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(lockPath, content, 0644)
+}
+
+func (l *Lock) Find(repo string) (*release.Pin, bool) {
+	for index := range l.Tools {
+		if strings.EqualFold(l.Tools[index].Repository, repo) {
+			return &l.Tools[index], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,89 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/maratoid/gh-install/platform"
+	"github.com/maratoid/gh-install/release"
+	"gopkg.in/yaml.v3"
+)
+
+const GH_INSTALL_MANIFEST_FILE = "gh-install.yaml"
+
+// declarative list of repository releases to install. Tool entries use the same fields as installation
+// flags ('repo', 'tag', 'path', 'download', 'download-regex', 'binary', 'binary-regex' etc.)
+type Manifest struct {
+	Path  string         `yaml:"path,omitempty"`
+	Tools []release.Spec `yaml:"tools"`
+}
+
+func expandHome(filePath string) string {
+	if filePath != "~" && !strings.HasPrefix(filePath, "~/") {
+		return filePath
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filePath
+	}
+	return path.Join(homeDir, strings.TrimPrefix(filePath, "~"))
+}
+
+func Load(manifestPath string) (*Manifest, error) {
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	if err = yaml.Unmarshal(content, manifest); err != nil {
+		return nil, fmt.Errorf("could not parse manifest %s: %v", manifestPath, err)
+	}
+
+	seen := make(map[string]bool)
+	for index := range manifest.Tools {
+		tool := &manifest.Tools[index]
+		if tool.Repository == "" {
+			return nil, fmt.Errorf("manifest %s: tool #%d has no 'repo'", manifestPath, index+1)
+		}
+		if len(strings.Split(tool.Repository, "/")) != 2 {
+			return nil, fmt.Errorf("manifest %s: repository must be in 'user/repository' format (provided: %s)",
+				manifestPath, tool.Repository)
+		}
+		if seen[strings.ToLower(tool.Repository)] {
+			return nil, fmt.Errorf("manifest %s: repository %s is listed more than once", manifestPath, tool.Repository)
+		}
+		seen[strings.ToLower(tool.Repository)] = true
+
+		var err error
+		if tool.OS, err = platform.ParseOS(tool.OS); err != nil {
+			return nil, fmt.Errorf("manifest %s: %s: %v", manifestPath, tool.Repository, err)
+		}
+		if tool.Arch, err = platform.ParseArch(tool.Arch); err != nil {
+			return nil, fmt.Errorf("manifest %s: %s: %v", manifestPath, tool.Repository, err)
+		}
+		if tool.Libc, err = platform.ParseLibc(tool.Libc); err != nil {
+			return nil, fmt.Errorf("manifest %s: %s: %v", manifestPath, tool.Repository, err)
+		}
+
+		if tool.InstallPath == "" {
+			tool.InstallPath = manifest.Path
+		}
+		tool.InstallPath = expandHome(tool.InstallPath)
+	}
+
+	return manifest, nil
+}
+
+// reports whether repository is listed in the manifest
+func (m *Manifest) Lists(repo string) bool {
+	for _, tool := range m.Tools {
+		if strings.EqualFold(tool.Repository, repo) {
+			return true
+		}
+	}
+	return false
+}
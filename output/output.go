@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 
@@ -14,7 +17,11 @@ import (
 	"golang.org/x/text/language"
 )
 
-var lock = &sync.Mutex{}
+var (
+	lock = &sync.Mutex{}
+	// snake_case output keys are printed title-cased, other keys (repositories) as they are
+	snakeCaseKeyRE = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
 
 type OutputMap struct {
 	sync.RWMutex
@@ -50,6 +57,42 @@ func (c *OutputMap) Get(key string) any {
 	return value
 }
 
+func (c *OutputMap) Reset() {
+	c.Lock()
+	c.content = make(map[string]interface{})
+	c.Unlock()
+}
+
+func formatKey(key string) string {
+	if !snakeCaseKeyRE.MatchString(key) {
+		return key
+	}
+	return cases.Title(language.Und).String(strings.ReplaceAll(key, "_", " "))
+}
+
+// formats maps as 'key (value), ...' sorted by key, maps nested in them as 'key: value, ...'
+func formatValue(value any, nested bool) string {
+	mapValue := reflect.ValueOf(value)
+	if mapValue.Kind() != reflect.Map {
+		return fmt.Sprintf("%v", value)
+	}
+
+	mapKeys := mapValue.MapKeys()
+	sort.Slice(mapKeys, func(i, j int) bool {
+		return fmt.Sprint(mapKeys[i].Interface()) < fmt.Sprint(mapKeys[j].Interface())
+	})
+	var entries []string
+	for _, mapKey := range mapKeys {
+		entryValue := formatValue(mapValue.MapIndex(mapKey).Interface(), true)
+		if nested {
+			entries = append(entries, fmt.Sprintf("%v: %s", mapKey.Interface(), entryValue))
+		} else {
+			entries = append(entries, fmt.Sprintf("%v (%s)", mapKey.Interface(), entryValue))
+		}
+	}
+	return strings.Join(entries, ", ")
+}
+
 func (c *OutputMap) Print(asJson bool) {
 	c.RLock()
 	defer c.RUnlock()
@@ -72,20 +115,19 @@ func (c *OutputMap) Print(asJson bool) {
 	} else {
 		printer := hpretty.NewTabPrinter(2)
 		printer.TabWidth(40)
-		for key, value := range c.content {
-			printer.Print(cases.Title(language.Und).String(strings.ReplaceAll(key, "_", " ")))
-
-			var printVal string
-			if s, ok := value.(map[string]string); ok {
-				for sKey, sValue := range s {
-					printVal = fmt.Sprintf("%s%v (%v), ", printVal, sKey, sValue)
-				}
-				printVal = strings.TrimSuffix(printVal, ", ")
-			} else {
-				printVal = fmt.Sprintf("%v", value)
-			}
-			printer.Print(printVal)
+
+		keys := make([]string, 0, len(c.content))
+		for key := range c.content {
+			keys = append(keys, key)
 		}
+		sort.Strings(keys)
 
+		for _, key := range keys {
+			value := c.content[key]
+			printer.Print(formatKey(key))
+
+			printer.Print(formatValue(value, false))
+		}
+		printer.Println()
 	}
 }
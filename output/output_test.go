@@ -0,0 +1,42 @@
+package output
+
+import "testing"
+
+func TestFormatKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"target_repository", "Target Repository"},
+		{"dry_run", "Dry Run"},
+		{"BurntSushi/ripgrep", "BurntSushi/ripgrep"},
+		{"cli/cli", "cli/cli"},
+	}
+
+	for _, test := range tests {
+		if formatted := formatKey(test.key); formatted != test.expected {
+			t.Errorf("formatKey(%q) = %q, expected %q", test.key, formatted, test.expected)
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected string
+	}{
+		{"v1.2.3", "v1.2.3"},
+		{true, "true"},
+		{map[string]string{"tool": "binary", "other": "compressed"}, "other (compressed), tool (binary)"},
+		{map[string]map[string]string{
+			"tool-1.0/bin/tool": {"name": "tool", "status": "installed", "type": "compressed"},
+		}, "tool-1.0/bin/tool (name: tool, status: installed, type: compressed)"},
+		{map[string]any{}, ""},
+	}
+
+	for _, test := range tests {
+		if formatted := formatValue(test.value, false); formatted != test.expected {
+			t.Errorf("formatValue(%v) = %q, expected %q", test.value, formatted, test.expected)
+		}
+	}
+}
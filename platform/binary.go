@@ -0,0 +1,184 @@
+package platform
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	GH_INSTALL_FORMAT_ELF     = "elf"
+	GH_INSTALL_FORMAT_MACHO   = "macho"
+	GH_INSTALL_FORMAT_PE      = "pe"
+	GH_INSTALL_FORMAT_SCRIPT  = "script"
+	GH_INSTALL_FORMAT_UNKNOWN = "unknown"
+)
+
+// executable format and architectures (more than one for Mach-O universal binaries) of a release binary
+type Binary struct {
+	Format string   `json:"format"`
+	Arch   []string `json:"arch,omitempty"`
+}
+
+func (b Binary) String() string {
+	if len(b.Arch) == 0 {
+		return b.Format
+	}
+	return fmt.Sprintf("%s %s", b.Format, strings.Join(b.Arch, ","))
+}
+
+func elfArch(file *elf.File) string {
+	littleEndian := file.Data == elf.ELFDATA2LSB
+	switch file.Machine {
+	case elf.EM_X86_64:
+		return "amd64"
+	case elf.EM_386:
+		return "386"
+	case elf.EM_AARCH64:
+		return "arm64"
+	case elf.EM_ARM:
+		return "arm"
+	case elf.EM_RISCV:
+		if file.Class == elf.ELFCLASS64 {
+			return "riscv64"
+		}
+		return "riscv"
+	case elf.EM_PPC64:
+		if littleEndian {
+			return "ppc64le"
+		}
+		return "ppc64"
+	case elf.EM_S390:
+		return "s390x"
+	case elf.EM_MIPS:
+		arch := "mips"
+		if file.Class == elf.ELFCLASS64 {
+			arch = "mips64"
+		}
+		if littleEndian {
+			arch += "le"
+		}
+		return arch
+	case elf.EM_LOONGARCH:
+		return "loong64"
+	}
+	return strings.ToLower(strings.TrimPrefix(file.Machine.String(), "EM_"))
+}
+
+func machoArch(cpu macho.Cpu) string {
+	switch cpu {
+	case macho.CpuAmd64:
+		return "amd64"
+	case macho.Cpu386:
+		return "386"
+	case macho.CpuArm64:
+		return "arm64"
+	case macho.CpuArm:
+		return "arm"
+	case macho.CpuPpc64:
+		return "ppc64"
+	case macho.CpuPpc:
+		return "ppc"
+	}
+	return strings.ToLower(strings.TrimPrefix(cpu.String(), "Cpu"))
+}
+
+func peArch(machine uint16) string {
+	switch machine {
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		return "amd64"
+	case pe.IMAGE_FILE_MACHINE_I386:
+		return "386"
+	case pe.IMAGE_FILE_MACHINE_ARM64:
+		return "arm64"
+	case pe.IMAGE_FILE_MACHINE_ARMNT, pe.IMAGE_FILE_MACHINE_ARM:
+		return "arm"
+	case pe.IMAGE_FILE_MACHINE_RISCV64:
+		return "riscv64"
+	case pe.IMAGE_FILE_MACHINE_LOONGARCH64:
+		return "loong64"
+	}
+	return fmt.Sprintf("0x%x", machine)
+}
+
+// detects executable format and architecture of binary contents
+func InspectBinary(reader io.ReaderAt) Binary {
+	if file, err := elf.NewFile(reader); err == nil {
+		return Binary{Format: GH_INSTALL_FORMAT_ELF, Arch: []string{elfArch(file)}}
+	}
+
+	if file, err := macho.NewFile(reader); err == nil {
+		return Binary{Format: GH_INSTALL_FORMAT_MACHO, Arch: []string{machoArch(file.Cpu)}}
+	}
+
+	if fatFile, err := macho.NewFatFile(reader); err == nil {
+		binary := Binary{Format: GH_INSTALL_FORMAT_MACHO}
+		for _, arch := range fatFile.Arches {
+			binary.Arch = append(binary.Arch, machoArch(arch.Cpu))
+		}
+		return binary
+	}
+
+	if file, err := pe.NewFile(reader); err == nil {
+		return Binary{Format: GH_INSTALL_FORMAT_PE, Arch: []string{peArch(file.Machine)}}
+	}
+
+	magic := make([]byte, 2)
+	if _, err := reader.ReadAt(magic, 0); err == nil && bytes.Equal(magic, []byte("#!")) {
+		return Binary{Format: GH_INSTALL_FORMAT_SCRIPT}
+	}
+
+	return Binary{Format: GH_INSTALL_FORMAT_UNKNOWN}
+}
+
+// reports whether file header starts with ELF, Mach-O, PE or script ('#!') magic
+func HasExecutableMagic(header []byte) bool {
+	magics := [][]byte{
+		[]byte("\x7fELF"),
+		{0xfe, 0xed, 0xfa, 0xce}, {0xce, 0xfa, 0xed, 0xfe},
+		{0xfe, 0xed, 0xfa, 0xcf}, {0xcf, 0xfa, 0xed, 0xfe},
+		{0xca, 0xfe, 0xba, 0xbe},
+		[]byte("MZ"),
+		[]byte("#!"),
+	}
+	for _, magic := range magics {
+		if bytes.HasPrefix(header, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// executable format binaries of the platform os use
+func (p Platform) binaryFormat() string {
+	switch p.OS {
+	case "darwin", "ios":
+		return GH_INSTALL_FORMAT_MACHO
+	case "windows":
+		return GH_INSTALL_FORMAT_PE
+	}
+	return GH_INSTALL_FORMAT_ELF
+}
+
+// checks that binary runs on the platform. Scripts and files of unknown format are not checked
+func (p Platform) CheckBinary(binary Binary) error {
+	switch binary.Format {
+	case GH_INSTALL_FORMAT_SCRIPT, GH_INSTALL_FORMAT_UNKNOWN:
+		return nil
+	}
+
+	if binary.Format != p.binaryFormat() {
+		return fmt.Errorf("%s binary does not run on %s (expected %s)", binary.Format, p.OS, p.binaryFormat())
+	}
+
+	for _, arch := range binary.Arch {
+		if arch == p.Arch {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s binary is built for %s, not %s", binary.Format, strings.Join(binary.Arch, ","), p.Arch)
+}
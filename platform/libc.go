@@ -0,0 +1,128 @@
+package platform
+
+import (
+	"debug/elf"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const (
+	GH_INSTALL_LIBC_GNU  = "gnu"
+	GH_INSTALL_LIBC_MUSL = "musl"
+)
+
+// asset name tokens identifying C library a Linux binary is built against
+var libcAliases = map[string][]string{
+	GH_INSTALL_LIBC_GNU:  {"gnu", "glibc", "gnueabihf", "gnueabi"},
+	GH_INSTALL_LIBC_MUSL: {"musl", "musleabihf", "musleabi", "alpine"},
+}
+
+var staticAliases = []string{"static", "staticx"}
+
+// normalizes '--libc' value, accepting 'glibc' for 'gnu'
+func ParseLibc(libc string) (string, error) {
+	switch strings.ToLower(libc) {
+	case "":
+		return "", nil
+	case "gnu", "glibc":
+		return GH_INSTALL_LIBC_GNU, nil
+	case "musl":
+		return GH_INSTALL_LIBC_MUSL, nil
+	}
+	return "", fmt.Errorf("unknown libc '%s', must be '%s' or '%s'", libc, GH_INSTALL_LIBC_GNU, GH_INSTALL_LIBC_MUSL)
+}
+
+// C library of Linux host: from the ELF interpreter of /bin/sh or, failing that, from 'ldd --version'.
+// Empty if not Linux or undetermined
+func DetectLibc() string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+
+	if interpreter, err := elfInterpreter("/bin/sh"); err == nil && interpreter != "" {
+		switch {
+		case strings.Contains(interpreter, "musl"):
+			return GH_INSTALL_LIBC_MUSL
+		case strings.Contains(interpreter, "ld-linux"):
+			return GH_INSTALL_LIBC_GNU
+		}
+	}
+
+	// musl ldd reports its version on stderr and exits non-zero
+	lddOutput, _ := exec.Command("ldd", "--version").CombinedOutput()
+	switch lower := strings.ToLower(string(lddOutput)); {
+	case strings.Contains(lower, "musl"):
+		return GH_INSTALL_LIBC_MUSL
+	case strings.Contains(lower, "gnu") || strings.Contains(lower, "glibc"):
+		return GH_INSTALL_LIBC_GNU
+	}
+
+	return ""
+}
+
+func elfInterpreter(filePath string) (string, error) {
+	elfFile, err := elf.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer elfFile.Close()
+
+	for _, prog := range elfFile.Progs {
+		if prog.Type != elf.PT_INTERP {
+			continue
+		}
+		interpreter := make([]byte, prog.Filesz)
+		if _, err := prog.ReadAt(interpreter, 0); err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(interpreter), "\x00"), nil
+	}
+
+	return "", nil
+}
+
+// scores libc variant of a Linux asset: matching libc first, then builds without libc in name, then static
+// and musl builds (which run on glibc hosts). Glibc builds can not run on musl hosts. If host libc is unknown,
+// static and musl builds are preferred as they run on either
+func (p Platform) libcScore(tokens map[string]bool) (int, string) {
+	if p.OS != "linux" {
+		return 0, ""
+	}
+
+	gnu := aliasIndex(tokens, libcAliases[GH_INSTALL_LIBC_GNU]) >= 0
+	musl := aliasIndex(tokens, libcAliases[GH_INSTALL_LIBC_MUSL]) >= 0
+	static := aliasIndex(tokens, staticAliases) >= 0
+
+	switch p.Libc {
+	case GH_INSTALL_LIBC_GNU:
+		switch {
+		case gnu:
+			return 4, "libc gnu matches host"
+		case static:
+			return 2, "static build"
+		case musl:
+			return 1, "libc musl fallback for gnu host"
+		}
+		return 3, "no libc in name"
+	case GH_INSTALL_LIBC_MUSL:
+		switch {
+		case musl:
+			return 4, "libc musl matches host"
+		case gnu:
+			return -1, "built for libc gnu"
+		case static:
+			return 3, "static build"
+		}
+		return 2, "no libc in name"
+	}
+
+	switch {
+	case static:
+		return 2, "static build for unknown host libc"
+	case musl:
+		return 1, "libc musl build for unknown host libc"
+	}
+	return 0, ""
+}
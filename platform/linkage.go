@@ -0,0 +1,222 @@
+package platform
+
+import (
+	"bufio"
+	"debug/elf"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/maratoid/gh-install/semver"
+)
+
+const glibcVersionPrefix = "GLIBC_"
+
+var (
+	// default library directories of the dynamic loader, searched after ld.so.conf directories
+	defaultLibraryDirs = []string{"/lib64", "/usr/lib64", "/lib", "/usr/lib"}
+	lddVersionRE       = regexp.MustCompile(`(\d+\.\d+)\s*$`)
+)
+
+// shared libraries and glibc version an ELF binary needs, and which of them the host lacks
+type Linkage struct {
+	Needed        []string `json:"needed,omitempty"`
+	GlibcRequired string   `json:"glibc_required,omitempty"`
+	GlibcHost     string   `json:"glibc_host,omitempty"`
+	Missing       []string `json:"missing,omitempty"`
+}
+
+// problems that prevent the binary from running on the host, nil if there are none
+func (l *Linkage) Problems() []string {
+	var problems []string
+	if l.GlibcRequired != "" && l.GlibcHost != "" && compareVersions(l.GlibcRequired, l.GlibcHost) > 0 {
+		problems = append(problems, fmt.Sprintf("requires glibc %s, host has glibc %s", l.GlibcRequired, l.GlibcHost))
+	}
+	if len(l.Missing) > 0 {
+		problems = append(problems, fmt.Sprintf("shared libraries not found on host: %s", strings.Join(l.Missing, ", ")))
+	}
+	return problems
+}
+
+func compareVersions(a string, b string) int {
+	aVersion, aErr := semver.Parse(a)
+	bVersion, bErr := semver.Parse(b)
+	if aErr != nil || bErr != nil {
+		return strings.Compare(a, b)
+	}
+	return aVersion.Compare(bVersion)
+}
+
+// highest 'GLIBC_x.y' version in versions
+func maxGlibcVersion(versions []string) string {
+	var maxVersion string
+	for _, version := range versions {
+		if !strings.HasPrefix(version, glibcVersionPrefix) {
+			continue
+		}
+		version = strings.TrimPrefix(version, glibcVersionPrefix)
+		if _, err := semver.Parse(version); err != nil {
+			continue
+		}
+		if maxVersion == "" || compareVersions(version, maxVersion) > 0 {
+			maxVersion = version
+		}
+	}
+	return maxVersion
+}
+
+// directories listed in ld.so.conf file, following 'include' directives
+func ldSoConfDirs(confPath string, seen map[string]bool) []string {
+	if seen[confPath] {
+		return nil
+	}
+	seen[confPath] = true
+
+	confFile, err := os.Open(confPath)
+	if err != nil {
+		return nil
+	}
+	defer confFile.Close()
+
+	var dirs []string
+	scanner := bufio.NewScanner(confFile)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if include, found := strings.CutPrefix(line, "include "); found {
+			pattern := strings.TrimSpace(include)
+			if !path.IsAbs(pattern) {
+				pattern = path.Join(path.Dir(confPath), pattern)
+			}
+			includes, _ := filepath.Glob(pattern)
+			for _, includePath := range includes {
+				dirs = append(dirs, ldSoConfDirs(includePath, seen)...)
+			}
+			continue
+		}
+		dirs = append(dirs, line)
+	}
+	return dirs
+}
+
+// directories the host dynamic loader searches for shared libraries
+func LibraryDirs() []string {
+	var dirs []string
+	for _, dir := range strings.Split(os.Getenv("LD_LIBRARY_PATH"), ":") {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	dirs = append(dirs, ldSoConfDirs("/etc/ld.so.conf", make(map[string]bool))...)
+	return append(dirs, defaultLibraryDirs...)
+}
+
+func findLibrary(name string, dirs []string) string {
+	if path.IsAbs(name) {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+		return ""
+	}
+
+	for _, dir := range dirs {
+		libraryPath := path.Join(dir, name)
+		if _, err := os.Stat(libraryPath); err == nil {
+			return libraryPath
+		}
+	}
+	return ""
+}
+
+// glibc version of the host: highest version libc.so.6 defines or, failing that, version 'ldd --version' reports
+func HostGlibcVersion() string {
+	if libcPath := findLibrary("libc.so.6", LibraryDirs()); libcPath != "" {
+		if libcFile, err := elf.Open(libcPath); err == nil {
+			defer libcFile.Close()
+			symbols, _ := libcFile.DynamicSymbols()
+			var versions []string
+			for _, symbol := range symbols {
+				versions = append(versions, symbol.Version)
+			}
+			if version := maxGlibcVersion(versions); version != "" {
+				return version
+			}
+		}
+	}
+
+	lddOutput, err := exec.Command("ldd", "--version").Output()
+	if err != nil {
+		return ""
+	}
+	firstLine, _, _ := strings.Cut(string(lddOutput), "\n")
+	if m := lddVersionRE.FindStringSubmatch(firstLine); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// reads DT_NEEDED shared libraries and 'GLIBC_x.y' symbol version requirements of an ELF binary and looks them
+// up on the host. Libraries found via '$ORIGIN' relative run paths can not be resolved before installation and
+// are not reported missing
+func InspectLinkage(reader io.ReaderAt) (*Linkage, error) {
+	elfFile, err := elf.NewFile(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer elfFile.Close()
+
+	linkage := &Linkage{}
+	linkage.Needed, err = elfFile.ImportedLibraries()
+	if err != nil {
+		return nil, err
+	}
+	if len(linkage.Needed) == 0 {
+		// statically linked
+		return linkage, nil
+	}
+
+	symbols, _ := elfFile.ImportedSymbols()
+	var glibcVersions []string
+	for _, symbol := range symbols {
+		glibcVersions = append(glibcVersions, symbol.Version)
+	}
+	linkage.GlibcRequired = maxGlibcVersion(glibcVersions)
+	if linkage.GlibcRequired != "" {
+		linkage.GlibcHost = HostGlibcVersion()
+	}
+
+	var runPaths []string
+	originRelative := false
+	for _, tag := range []elf.DynTag{elf.DT_RUNPATH, elf.DT_RPATH} {
+		values, _ := elfFile.DynString(tag)
+		for _, value := range values {
+			for _, runPath := range strings.Split(value, ":") {
+				if strings.Contains(runPath, "$ORIGIN") || strings.Contains(runPath, "${ORIGIN}") {
+					originRelative = true
+					continue
+				}
+				runPaths = append(runPaths, runPath)
+			}
+		}
+	}
+
+	if !originRelative {
+		dirs := append(runPaths, LibraryDirs()...)
+		for _, library := range linkage.Needed {
+			if findLibrary(library, dirs) == "" {
+				linkage.Missing = append(linkage.Missing, library)
+			}
+		}
+	}
+
+	return linkage, nil
+}
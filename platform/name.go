@@ -0,0 +1,155 @@
+package platform
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	nameToken = iota
+	versionToken
+	// pre-release label ('rc', 'beta.2'), only trimmed after a version
+	prereleaseToken
+	// os, arch or libc
+	platformToken
+	// universal and build tokens, only trimmed after an os or arch
+	buildToken
+)
+
+var (
+	nameSeparatorRE = regexp.MustCompile(`[-_.]`)
+	versionTokenRE  = regexp.MustCompile(`^[vV]?\d+$`)
+	prereleaseRE    = regexp.MustCompile(`^(?i:rc|alpha|beta|pre|preview|dev|snapshot)\d*$`)
+	// vendor and build tokens of target triples ('x86_64-unknown-linux-musl', 'x86_64-pc-windows-msvc')
+	buildTokens = []string{"unknown", "pc", "none", "msvc", "static", "staticx"}
+)
+
+type nameTokenSpan struct {
+	text string
+	// separator preceding the token
+	separator string
+	start     int
+}
+
+func isAlias(token string, aliasTables ...map[string][]string) bool {
+	token = strings.ToLower(token)
+	for _, aliasTable := range aliasTables {
+		for _, aliases := range aliasTable {
+			for _, alias := range aliases {
+				if alias == token {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// splits name at separators, keeping multi-part aliases such as 'x86_64' and 'aarch64_be' together
+func splitName(name string) []nameTokenSpan {
+	var tokens []nameTokenSpan
+	start, separator := 0, ""
+	for _, loc := range nameSeparatorRE.FindAllStringIndex(name, -1) {
+		tokens = append(tokens, nameTokenSpan{text: name[start:loc[0]], separator: separator, start: start})
+		start, separator = loc[1], name[loc[0]:loc[1]]
+	}
+	tokens = append(tokens, nameTokenSpan{text: name[start:], separator: separator, start: start})
+
+	var merged []nameTokenSpan
+	for index := 0; index < len(tokens); index++ {
+		token := tokens[index]
+		if index+1 < len(tokens) && tokens[index+1].separator != "." {
+			joined := token.text + tokens[index+1].separator + tokens[index+1].text
+			if isAlias(joined, archAliases) {
+				token.text = joined
+				index++
+			}
+		}
+		merged = append(merged, token)
+	}
+	return merged
+}
+
+// kind of token. Numbers are only versions as part of a dotted version or at the end of the name
+func tokenKind(tokens []nameTokenSpan, index int) int {
+	token := tokens[index]
+	last := index+1 == len(tokens)
+	switch {
+	case versionTokenRE.MatchString(token.text) &&
+		(last || token.separator == "." || tokens[index+1].separator == "."):
+		return versionToken
+	case prereleaseRE.MatchString(token.text):
+		return prereleaseToken
+	case isAlias(token.text, osAliases, archAliases, libcAliases):
+		return platformToken
+	case isAlias(token.text, universalAliases):
+		return buildToken
+	}
+	for _, build := range buildTokens {
+		if strings.EqualFold(build, token.text) {
+			return buildToken
+		}
+	}
+	return nameToken
+}
+
+// cuts the trailing run of tokens of trimmed kinds from binary name, keeping the first token and '.exe'
+// extension. Pre-release tokens are only cut after a version, build tokens only after an os or arch
+func trimName(name string, trimmed ...int) string {
+	ext := path.Ext(name)
+	if !strings.EqualFold(ext, ".exe") {
+		ext = ""
+	}
+	base := strings.TrimSuffix(name, ext)
+
+	tokens := splitName(base)
+	kinds := make([]int, len(tokens))
+	cut := len(tokens)
+	for index := len(tokens) - 1; index > 0; index-- {
+		kinds[index] = tokenKind(tokens, index)
+		found := false
+		for _, kind := range trimmed {
+			found = found || kinds[index] == kind
+		}
+		if !found {
+			break
+		}
+		cut = index
+	}
+
+	// the run starts after the last pre-release or build token that nothing in the run qualifies
+	var afterVersion, afterPlatform bool
+	for index := cut; index < len(tokens); index++ {
+		switch kinds[index] {
+		case versionToken:
+			afterVersion = true
+		case platformToken:
+			afterPlatform = true
+		case prereleaseToken:
+			if !afterVersion {
+				cut, afterPlatform = index+1, false
+			}
+		case buildToken:
+			if !afterPlatform {
+				cut, afterVersion = index+1, false
+			}
+		}
+	}
+
+	if cut == len(tokens) {
+		return name
+	}
+	return base[:tokens[cut].start-len(tokens[cut].separator)] + ext
+}
+
+// removes trailing version and platform tokens from binary name: 'tool_v1.4.0_linux_amd64' and
+// 'tool-x86_64-unknown-linux-musl' become 'tool', 'tool-all-features-linux-amd64' becomes 'tool-all-features'
+func TrimPlatform(name string) string {
+	return trimName(name, versionToken, prereleaseToken, platformToken, buildToken)
+}
+
+// removes trailing version from binary name: 'tool-1.4.0' becomes 'tool'
+func TrimVersion(name string) string {
+	return trimName(name, versionToken, prereleaseToken)
+}
@@ -0,0 +1,59 @@
+package platform
+
+import "testing"
+
+func TestTrimPlatform(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"tool", "tool"},
+		{"tool_v1.4.0_linux_amd64", "tool"},
+		{"tool-1.4.0-linux-amd64", "tool"},
+		{"tool-x86_64-unknown-linux-musl", "tool"},
+		{"tool-x86_64-pc-windows-msvc.exe", "tool.exe"},
+		{"tool-windows-amd64.exe", "tool.exe"},
+		{"tool-darwin-universal", "tool"},
+		{"tool-linux-amd64-static", "tool"},
+		{"tool-aarch64-linux-gnu", "tool"},
+		{"tool-1.2.3-rc.1-linux-amd64", "tool"},
+		{"tool-linux-x86-64", "tool"},
+		{"tool-all-features-linux-amd64", "tool-all-features"},
+		{"go-static-server-linux-amd64", "go-static-server"},
+		{"arm-none-eabi-gdb", "arm-none-eabi-gdb"},
+		{"arm-none-eabi", "arm-none-eabi"},
+		{"tool-static", "tool-static"},
+		{"tool-all", "tool-all"},
+		{"tool-pc", "tool-pc"},
+		{"tool-rc", "tool-rc"},
+		{"tool-static-linux-amd64", "tool-static"},
+		{"linux-amd64", "linux"},
+		{"python3", "python3"},
+	}
+
+	for _, test := range tests {
+		if trimmed := TrimPlatform(test.name); trimmed != test.expected {
+			t.Errorf("TrimPlatform(%q) = %q, expected %q", test.name, trimmed, test.expected)
+		}
+	}
+}
+
+func TestTrimVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"tool-1.4.0", "tool"},
+		{"tool_v2", "tool"},
+		{"tool-1.2.3-rc.1", "tool"},
+		{"tool-1.4.0-linux-amd64", "tool-1.4.0-linux-amd64"},
+		{"tool-rc", "tool-rc"},
+		{"tool-2x", "tool-2x"},
+	}
+
+	for _, test := range tests {
+		if trimmed := TrimVersion(test.name); trimmed != test.expected {
+			t.Errorf("TrimVersion(%q) = %q, expected %q", test.name, trimmed, test.expected)
+		}
+	}
+}
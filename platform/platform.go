@@ -0,0 +1,251 @@
+package platform
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// target operating system and architecture of release binaries
+type Platform struct {
+	OS   string `json:"os" yaml:"os"`
+	Arch string `json:"arch" yaml:"arch"`
+	Libc string `json:"libc,omitempty" yaml:"libc,omitempty"`
+}
+
+var (
+	// libc of the host, detected once since detection reads /bin/sh or runs 'ldd'
+	hostLibc     string
+	hostLibcOnce sync.Once
+)
+
+func Host() Platform {
+	hostLibcOnce.Do(func() {
+		hostLibc = DetectLibc()
+	})
+	return Platform{
+		OS:   runtime.GOOS,
+		Arch: runtime.GOARCH,
+		Libc: hostLibc,
+	}
+}
+
+func (p Platform) String() string {
+	if p.Libc != "" {
+		return fmt.Sprintf("%s/%s (%s)", p.OS, p.Arch, p.Libc)
+	}
+	return fmt.Sprintf("%s/%s", p.OS, p.Arch)
+}
+
+// reports whether platforms have the same os and arch, and the same libc if both libcs are known
+func (p Platform) Compatible(other Platform) bool {
+	return p.OS == other.OS && p.Arch == other.Arch && (p.Libc == "" || other.Libc == "" || p.Libc == other.Libc)
+}
+
+// reports whether binaries built for the platform run on the host
+func (p Platform) IsHost() bool {
+	return p.Compatible(Host())
+}
+
+// normalizes alias ('macos', 'x86_64', 'aarch64') to GOOS or GOARCH name of aliases
+func parseAlias(kind string, value string, aliases map[string][]string) (string, error) {
+	lowerValue := strings.ToLower(value)
+	if lowerValue == "" {
+		return "", nil
+	}
+	if _, found := aliases[lowerValue]; found {
+		return lowerValue, nil
+	}
+	for name, nameAliases := range aliases {
+		for _, alias := range nameAliases {
+			if alias == lowerValue {
+				return name, nil
+			}
+		}
+	}
+
+	var names []string
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("unknown %s '%s', must be one of: %s", kind, value, strings.Join(names, ", "))
+}
+
+func ParseOS(os string) (string, error) {
+	return parseAlias("os", os, osAliases)
+}
+
+func ParseArch(arch string) (string, error) {
+	return parseAlias("arch", arch, archAliases)
+}
+
+// target platform with os, arch and libc overridden where set. Host libc is only assumed for the host os and arch
+func Target(os string, arch string, libc string) Platform {
+	target := Host()
+	if (os != "" && os != target.OS) || (arch != "" && arch != target.Arch) {
+		target.Libc = ""
+	}
+	if os != "" {
+		target.OS = os
+	}
+	if arch != "" {
+		target.Arch = arch
+	}
+	if libc != "" {
+		target.Libc = libc
+	}
+	return target
+}
+
+// asset name tokens identifying operating systems, by GOOS
+var osAliases = map[string][]string{
+	"linux":   {"linux"},
+	"darwin":  {"darwin", "macos", "macosx", "osx", "mac", "apple"},
+	"windows": {"windows", "win", "win32", "win64", "exe", "msi"},
+	"freebsd": {"freebsd"},
+	"openbsd": {"openbsd"},
+	"netbsd":  {"netbsd"},
+	"android": {"android"},
+	"illumos": {"illumos"},
+	"solaris": {"solaris", "sunos"},
+}
+
+// asset name tokens identifying architectures, by GOARCH, best match first
+var archAliases = map[string][]string{
+	"amd64":   {"amd64", "x86_64", "x64", "amd64v1", "x86-64", "amd64v2", "amd64v3", "amd64v4"},
+	"386":     {"386", "i386", "i686", "x86", "x32", "32bit", "ia32"},
+	"arm64":   {"arm64", "aarch64", "arm64v8", "armv8", "aarch64_be"},
+	"arm":     {"armv7", "armv7l", "armhf", "armv7hf", "arm", "armv6", "armv6l", "armel", "armv5"},
+	"riscv64": {"riscv64", "riscv64gc"},
+	"ppc64le": {"ppc64le", "powerpc64le"},
+	"ppc64":   {"ppc64", "powerpc64"},
+	"s390x":   {"s390x"},
+	"mips64":  {"mips64"},
+	"mipsle":  {"mipsle", "mipsel"},
+	"mips":    {"mips"},
+	"loong64": {"loong64", "loongarch64"},
+}
+
+// tokens of fat binaries that run on every architecture of an operating system
+var universalAliases = map[string][]string{
+	"darwin": {"universal", "universal2", "all"},
+}
+
+// asset extensions that are never installable release binaries: checksums, signatures, certificates, SBOMs
+var auxiliaryRE = regexp.MustCompile(`(?i)(?:\.(?:sha1|sha256|sha512|md5|sum|sig|asc|pem|crt|cert|bundle|sbom|spdx|cdx|intoto\.jsonl|att|txt|json|ya?ml|md)|checksums?|sha256sums|sha512sums)$`)
+
+// multi-part aliases that tokenization would split apart
+var compoundAliases = strings.NewReplacer("x86_64", "amd64", "x86-64", "amd64", "aarch64_be", "aarch64")
+
+var tokenRE = regexp.MustCompile(`[a-z0-9]+`)
+
+func tokenize(name string) []string {
+	return tokenRE.FindAllString(compoundAliases.Replace(strings.ToLower(name)), -1)
+}
+
+// index of first token found in aliases, -1 if none is found
+func aliasIndex(tokens map[string]bool, aliases []string) int {
+	for index, alias := range aliases {
+		if tokens[compoundAliases.Replace(alias)] {
+			return index
+		}
+	}
+	return -1
+}
+
+func formatScore(name string, target Platform) (int, string) {
+	lowerName := strings.ToLower(name)
+	archives := []string{".tar.gz", ".tgz", ".tar.xz", ".txz", ".tar.bz2", ".tbz", ".tar.zst"}
+	if target.OS == "windows" {
+		archives = []string{".zip", ".exe"}
+	}
+	for _, ext := range archives {
+		if strings.HasSuffix(lowerName, ext) {
+			return 3, ext
+		}
+	}
+
+	for _, ext := range []string{".zip", ".7z", ".gz", ".xz", ".bz2"} {
+		if strings.HasSuffix(lowerName, ext) {
+			return 2, ext
+		}
+	}
+
+	// system packages need root to install and are only picked if there is nothing else
+	for _, ext := range []string{".deb", ".rpm", ".apk", ".pkg", ".dmg", ".msi", ".appimage", ".snap", ".flatpak"} {
+		if strings.HasSuffix(lowerName, ext) {
+			return -2, ext
+		}
+	}
+
+	return 1, "binary"
+}
+
+// scores how well release asset name matches the target platform. Assets that can not be installed on the
+// target platform score below zero. Reasons explain the score
+func (p Platform) ScoreAsset(name string) (int, []string) {
+	if auxiliaryRE.MatchString(name) {
+		return -1, []string{"auxiliary file (checksum, signature, certificate or metadata)"}
+	}
+
+	tokens := make(map[string]bool)
+	for _, token := range tokenize(name) {
+		tokens[token] = true
+	}
+
+	var score int
+	var reasons []string
+
+	if index := aliasIndex(tokens, osAliases[p.OS]); index >= 0 {
+		score += 20
+		reasons = append(reasons, fmt.Sprintf("os %s matches '%s'", p.OS, osAliases[p.OS][index]))
+	} else {
+		for otherOS, aliases := range osAliases {
+			if otherOS == p.OS {
+				continue
+			}
+			if index := aliasIndex(tokens, aliases); index >= 0 {
+				return -1, []string{fmt.Sprintf("built for os %s ('%s')", otherOS, aliases[index])}
+			}
+		}
+		reasons = append(reasons, "no os in name")
+	}
+
+	if index := aliasIndex(tokens, archAliases[p.Arch]); index >= 0 {
+		// preferred aliases come first: plain 'amd64' is preferred over 'amd64v3' builds
+		score += 10 + len(archAliases[p.Arch]) - index
+		reasons = append(reasons, fmt.Sprintf("arch %s matches '%s'", p.Arch, archAliases[p.Arch][index]))
+	} else if index := aliasIndex(tokens, universalAliases[p.OS]); index >= 0 {
+		score += 10
+		reasons = append(reasons, fmt.Sprintf("universal binary '%s'", universalAliases[p.OS][index]))
+	} else {
+		for otherArch, aliases := range archAliases {
+			if otherArch == p.Arch {
+				continue
+			}
+			if index := aliasIndex(tokens, aliases); index >= 0 {
+				return -1, []string{fmt.Sprintf("built for arch %s ('%s')", otherArch, aliases[index])}
+			}
+		}
+		reasons = append(reasons, "no arch in name")
+	}
+
+	libcPoints, libcReason := p.libcScore(tokens)
+	if libcPoints < 0 {
+		return -1, []string{libcReason}
+	}
+	score += libcPoints
+	if libcReason != "" {
+		reasons = append(reasons, libcReason)
+	}
+
+	formatPoints, format := formatScore(name, p)
+	score += formatPoints
+	reasons = append(reasons, fmt.Sprintf("format %s", format))
+
+	return score, reasons
+}
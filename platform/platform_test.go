@@ -0,0 +1,90 @@
+package platform
+
+import "testing"
+
+func TestScoreAssetRejects(t *testing.T) {
+	linux := Platform{OS: "linux", Arch: "amd64", Libc: GH_INSTALL_LIBC_GNU}
+	tests := []struct {
+		target Platform
+		name   string
+	}{
+		{linux, "tool_1.0.0_checksums.txt"},
+		{linux, "tool_linux_amd64.tar.gz.sha256"},
+		{linux, "tool_linux_amd64.tar.gz.sig"},
+		{linux, "tool_darwin_amd64.tar.gz"},
+		{linux, "tool-x86_64-pc-windows-msvc.zip"},
+		{linux, "tool_linux_arm64.tar.gz"},
+		{linux, "tool-aarch64-unknown-linux-gnu.tar.gz"},
+		{linux, "tool_linux_386.tar.gz"},
+		{Platform{OS: "linux", Arch: "amd64", Libc: GH_INSTALL_LIBC_MUSL}, "tool-x86_64-unknown-linux-gnu.tar.gz"},
+		{Platform{OS: "darwin", Arch: "arm64"}, "tool_linux_arm64.tar.gz"},
+		{Platform{OS: "darwin", Arch: "arm64"}, "tool_darwin_amd64.tar.gz"},
+	}
+
+	for _, test := range tests {
+		if score, reasons := test.target.ScoreAsset(test.name); score >= 0 {
+			t.Errorf("%s scores %d %v for %s, expected rejection", test.name, score, reasons, test.target)
+		}
+	}
+}
+
+func TestScoreAssetPrefers(t *testing.T) {
+	gnu := Platform{OS: "linux", Arch: "amd64", Libc: GH_INSTALL_LIBC_GNU}
+	musl := Platform{OS: "linux", Arch: "amd64", Libc: GH_INSTALL_LIBC_MUSL}
+	unknownLibc := Platform{OS: "linux", Arch: "amd64"}
+	darwin := Platform{OS: "darwin", Arch: "arm64"}
+	windows := Platform{OS: "windows", Arch: "amd64"}
+
+	tests := []struct {
+		target    Platform
+		preferred string
+		other     string
+	}{
+		{gnu, "tool_linux_amd64.tar.gz", "tool_amd64.tar.gz"},
+		{gnu, "tool_linux_amd64.tar.gz", "tool_linux.tar.gz"},
+		{gnu, "tool_linux_amd64.tar.gz", "tool_linux_amd64v3.tar.gz"},
+		{gnu, "tool-x86_64-unknown-linux-gnu.tar.gz", "tool-x86_64-unknown-linux-musl.tar.gz"},
+		{gnu, "tool-linux-amd64-static.tar.gz", "tool-x86_64-unknown-linux-musl.tar.gz"},
+		{gnu, "tool_linux_amd64.tar.gz", "tool_linux_amd64.zip"},
+		{gnu, "tool_linux_amd64.gz", "tool_linux_amd64.deb"},
+		{musl, "tool-x86_64-unknown-linux-musl.tar.gz", "tool-linux-amd64-static.tar.gz"},
+		{musl, "tool-linux-amd64-static.tar.gz", "tool_linux_amd64.tar.gz"},
+		{unknownLibc, "tool-linux-amd64-static.tar.gz", "tool-x86_64-unknown-linux-musl.tar.gz"},
+		{unknownLibc, "tool-x86_64-unknown-linux-musl.tar.gz", "tool-x86_64-unknown-linux-gnu.tar.gz"},
+		{darwin, "tool_macos_arm64.tar.gz", "tool_darwin_universal.tar.gz"},
+		{darwin, "tool_darwin_universal.tar.gz", "tool_darwin.tar.gz"},
+		{windows, "tool_windows_amd64.zip", "tool_windows_amd64.7z"},
+		{windows, "tool_windows_amd64.exe", "tool_windows_amd64.msi"},
+	}
+
+	for _, test := range tests {
+		preferred, preferredReasons := test.target.ScoreAsset(test.preferred)
+		other, otherReasons := test.target.ScoreAsset(test.other)
+		if preferred <= other {
+			t.Errorf("%s scores %d %v, not above %s %d %v for %s", test.preferred, preferred, preferredReasons,
+				test.other, other, otherReasons, test.target)
+		}
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	gnu := Platform{OS: "linux", Arch: "amd64", Libc: GH_INSTALL_LIBC_GNU}
+	tests := []struct {
+		platform Platform
+		other    Platform
+		expected bool
+	}{
+		{gnu, gnu, true},
+		{gnu, Platform{OS: "linux", Arch: "amd64"}, true},
+		{Platform{OS: "linux", Arch: "amd64"}, gnu, true},
+		{gnu, Platform{OS: "linux", Arch: "amd64", Libc: GH_INSTALL_LIBC_MUSL}, false},
+		{gnu, Platform{OS: "linux", Arch: "arm64", Libc: GH_INSTALL_LIBC_GNU}, false},
+		{Platform{OS: "darwin", Arch: "arm64"}, Platform{OS: "linux", Arch: "arm64"}, false},
+	}
+
+	for _, test := range tests {
+		if compatible := test.platform.Compatible(test.other); compatible != test.expected {
+			t.Errorf("%s compatible with %s = %v, expected %v", test.platform, test.other, compatible, test.expected)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/maratoid/gh-install/release"
+	"github.com/maratoid/gh-install/store"
+)
+
+const (
+	registryFileName = "registry.json"
+	previousDirName  = "previous"
+)
+
+// installed repository: receipt of its current installation and of the installation it replaced
+type Entry struct {
+	release.Receipt
+	Previous *Previous `json:"previous,omitempty"`
+}
+
+// installation replaced by the current one. Kept maps installed file paths to copies kept in Dir, files that
+// were modified or missing when they were replaced and symbolic links are not kept
+type Previous struct {
+	release.Receipt
+	Dir  string            `json:"dir,omitempty"`
+	Kept map[string]string `json:"kept,omitempty"`
+}
+
+// reports whether all files of the previous installation were kept, so that it can be restored offline
+func (p *Previous) Complete() bool {
+	if len(p.Files) == 0 {
+		return false
+	}
+	for _, file := range p.Files {
+		if file.Target != "" {
+			continue
+		}
+		keptPath, found := p.Kept[file.Path]
+		if !found {
+			return false
+		}
+		if _, err := os.Stat(keptPath); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// removes copies kept for the previous installation
+func (p *Previous) Discard() error {
+	if p == nil || p.Dir == "" {
+		return nil
+	}
+	return os.RemoveAll(p.Dir)
+}
+
+type Registry struct {
+	path    string
+	Entries map[string]*Entry `json:"entries"`
+}
+
+func registryKey(repo string) string {
+	return strings.ToLower(repo)
+}
+
+func Load() (*Registry, error) {
+	dataDir := store.DataDir()
+	if dataDir == "" {
+		return nil, fmt.Errorf("could not determine gh-install data directory")
+	}
+
+	registry := &Registry{
+		path:    path.Join(dataDir, registryFileName),
+		Entries: make(map[string]*Entry),
+	}
+
+	content, err := os.ReadFile(registry.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+		return nil, err
+	}
+
+	if err = json.Unmarshal(content, registry); err != nil {
+		return nil, fmt.Errorf("could not parse registry %s: %v", registry.path, err)
+	}
+	if registry.Entries == nil {
+		registry.Entries = make(map[string]*Entry)
+	}
+
+	return registry, nil
+}
+
+func (r *Registry) Save() error {
+	err := os.MkdirAll(path.Dir(r.path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(path.Dir(r.path), registryFileName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err = tempFile.Write(content); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err = tempFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), r.path)
+}
+
+func (r *Registry) Get(repo string) (*Entry, bool) {
+	entry, found := r.Entries[registryKey(repo)]
+	return entry, found
+}
+
+func (r *Registry) Record(receipt *release.Receipt) *Entry {
+	entry := &Entry{Receipt: *receipt}
+	r.Entries[registryKey(receipt.Spec.Repository)] = entry
+	return entry
+}
+
+// copies unmodified files of the entry's installation to the data directory, so that the installation can be
+// restored after it is replaced
+func (r *Registry) Keep(entry *Entry) (*Previous, error) {
+	if entry.Spec.Store {
+		// store keeps versions itself
+		return &Previous{Receipt: entry.Receipt}, nil
+	}
+
+	previousRoot := path.Join(path.Dir(r.path), previousDirName, registryKey(entry.Spec.Repository))
+	if err := os.MkdirAll(previousRoot, os.ModePerm); err != nil {
+		return nil, err
+	}
+	keepDir, err := os.MkdirTemp(previousRoot, "*")
+	if err != nil {
+		return nil, err
+	}
+
+	previous := &Previous{
+		Receipt: entry.Receipt,
+		Dir:     keepDir,
+		Kept:    make(map[string]string),
+	}
+	for index, file := range entry.Files {
+		// symbolic links are restored from their recorded targets
+		if file.Target != "" {
+			continue
+		}
+		if modified, err := file.Modified(); err != nil || modified {
+			continue
+		}
+
+		keptPath := path.Join(keepDir, fmt.Sprintf("%d-%s", index, path.Base(file.Path)))
+		if err := copyFile(file.Path, keptPath); err != nil {
+			previous.Discard()
+			return nil, err
+		}
+		previous.Kept[file.Path] = keptPath
+	}
+
+	return previous, nil
+}
+
+func copyFile(sourcePath string, destinationPath string) error {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	destination, err := os.OpenFile(destinationPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(destination, source); err != nil {
+		destination.Close()
+		return err
+	}
+	return destination.Close()
+}
+
+func (r *Registry) Remove(repo string) {
+	delete(r.Entries, registryKey(repo))
+}
+
+// sorted repositories of all registry entries
+func (r *Registry) Repositories() []string {
+	var repos []string
+	for _, entry := range r.Entries {
+		repos = append(repos, entry.Spec.Repository)
+	}
+	sort.Strings(repos)
+
+	return repos
+}
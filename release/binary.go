@@ -0,0 +1,136 @@
+package release
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/maratoid/gh-install/platform"
+	"github.com/maratoid/gh-install/selector"
+)
+
+// contents of an archived binary that can be inspected: archive files are not seekable and are read into memory
+func readerAt(file io.Reader) (io.ReaderAt, io.Reader, error) {
+	if seekable, ok := file.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		return seekable, file, nil
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	contentReader := bytes.NewReader(content)
+	return contentReader, contentReader, nil
+}
+
+// reports whether selected archive binary is a symbolic link in the archive
+func isSymlinkItem(binary *selector.SelectorItem) bool {
+	mode, ok := binary.GetProp("mode").(fs.FileMode)
+	return ok && mode&fs.ModeSymlink != 0
+}
+
+// binaries with executable mode bits or executable format (ELF, Mach-O, PE, script) contents
+func executableBinaries(binaries []*selector.SelectorItem) ([]*selector.SelectorItem, error) {
+	var executables []*selector.SelectorItem
+	for _, binary := range binaries {
+		if mode, ok := binary.GetProp("mode").(fs.FileMode); ok && mode&0111 != 0 {
+			executables = append(executables, binary)
+			continue
+		}
+
+		var file io.ReadCloser
+		var err error
+		if binary.GetPropBool("archive") {
+			file, err = binary.GetPropFs("fs").Open(binary.GetPropStr("path"))
+		} else {
+			file, err = os.Open(binary.GetPropStr("path"))
+		}
+		if err != nil {
+			return nil, err
+		}
+		header := make([]byte, 4)
+		_, err = io.ReadFull(file, header)
+		file.Close()
+		if err == nil && platform.HasExecutableMagic(header) {
+			executables = append(executables, binary)
+		}
+	}
+
+	if len(executables) == 0 {
+		return nil, fmt.Errorf("no executable release asset binaries found")
+	}
+	return executables, nil
+}
+
+// checks executable format and architecture of binary against the target platform. Mismatches fail the
+// installation unless forced
+func (r *GithubRelease) checkBinary(name string, reader io.ReaderAt) error {
+	binary := platform.InspectBinary(reader)
+	target := r.TargetPlatform()
+
+	checkOutput := map[string]string{
+		"format": binary.Format,
+		"arch":   strings.Join(binary.Arch, ","),
+		"status": "compatible",
+	}
+	if r.binaryChecks == nil {
+		r.binaryChecks = make(map[string]map[string]string)
+	}
+	r.binaryChecks[name] = checkOutput
+
+	switch binary.Format {
+	case platform.GH_INSTALL_FORMAT_SCRIPT, platform.GH_INSTALL_FORMAT_UNKNOWN:
+		checkOutput["status"] = "unchecked"
+		return nil
+	}
+
+	err := target.CheckBinary(binary)
+	if err != nil {
+		checkOutput["reason"] = err.Error()
+		if r.Force {
+			checkOutput["status"] = "forced"
+			return nil
+		}
+		checkOutput["status"] = "mismatch"
+		return fmt.Errorf("refusing to install %s for %s: %v (use '--force' to install anyway)", name, target, err)
+	}
+
+	// shared libraries can only be looked up when installing for the host
+	if binary.Format != platform.GH_INSTALL_FORMAT_ELF || target.OS != "linux" || !target.IsHost() {
+		return nil
+	}
+	return r.checkLinkage(name, reader, checkOutput)
+}
+
+// checks shared libraries and glibc version binary needs against the host. Problems are reported as warnings,
+// or fail the installation if strict
+func (r *GithubRelease) checkLinkage(name string, reader io.ReaderAt, checkOutput map[string]string) error {
+	linkage, err := platform.InspectLinkage(reader)
+	if err != nil {
+		return err
+	}
+
+	checkOutput["needed"] = strings.Join(linkage.Needed, ",")
+	checkOutput["glibc_required"] = linkage.GlibcRequired
+	checkOutput["glibc_host"] = linkage.GlibcHost
+	checkOutput["missing_libraries"] = strings.Join(linkage.Missing, ",")
+
+	problems := linkage.Problems()
+	if len(problems) == 0 {
+		return nil
+	}
+
+	checkOutput["reason"] = strings.Join(problems, "; ")
+	if r.Strict {
+		checkOutput["status"] = "unrunnable"
+		return fmt.Errorf("refusing to install %s: %s", name, checkOutput["reason"])
+	}
+	checkOutput["status"] = "warning"
+	return nil
+}
@@ -0,0 +1,291 @@
+package release
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cli/go-gh/v2"
+	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/selector"
+)
+
+var (
+	bsdChecksumRE  = regexp.MustCompile(`^(MD5|SHA1|SHA224|SHA256|SHA384|SHA512)\s*\((.+)\)\s*=\s*([0-9a-fA-F]+)$`)
+	gnuChecksumRE  = regexp.MustCompile(`^([0-9a-fA-F]+)\s+[*]?(.+)$`)
+	bareChecksumRE = regexp.MustCompile(`^([0-9a-fA-F]+)$`)
+	signatureRE    = regexp.MustCompile(`(?i)\.(?:sig|asc|pem|crt|cert|bundle|sbom|intoto\.jsonl)$`)
+)
+
+type checksum struct {
+	Algorithm string
+	Value     string
+}
+
+func newHash(algorithm string) (hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha224":
+		return sha256.New224(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha384":
+		return sha512.New384(), nil
+	case "sha512":
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported checksum algorithm '%s'", algorithm)
+}
+
+func algorithmForDigest(digest string) string {
+	switch len(digest) {
+	case 32:
+		return "md5"
+	case 40:
+		return "sha1"
+	case 56:
+		return "sha224"
+	case 64:
+		return "sha256"
+	case 96:
+		return "sha384"
+	case 128:
+		return "sha512"
+	}
+	return ""
+}
+
+func hashFile(filePath string, algorithm string) (string, error) {
+	hasher, err := newHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err = io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// finds checksum for assetName in the contents of checksum file checksumName. Supports GNU coreutils
+// ('<digest>  <file>'), BSD ('SHA256 (<file>) = <digest>') and single digest per-asset files ('<asset>.sha256')
+func findChecksum(content []byte, checksumName string, assetName string) (*checksum, bool) {
+	perAsset := strings.HasPrefix(checksumName, assetName+".")
+	extAlgorithm := strings.ToLower(strings.TrimPrefix(path.Ext(checksumName), "."))
+	if _, err := newHash(extAlgorithm); err != nil {
+		extAlgorithm = ""
+	}
+
+	makeChecksum := func(digest string, algorithm string) (*checksum, bool) {
+		if algorithm == "" {
+			algorithm = algorithmForDigest(digest)
+		}
+		if algorithm == "" {
+			return nil, false
+		}
+		return &checksum{Algorithm: algorithm, Value: strings.ToLower(digest)}, true
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if m := bsdChecksumRE.FindStringSubmatch(line); m != nil {
+			if path.Base(strings.TrimSpace(m[2])) == assetName {
+				return makeChecksum(m[3], strings.ToLower(m[1]))
+			}
+			continue
+		}
+
+		if m := gnuChecksumRE.FindStringSubmatch(line); m != nil {
+			if path.Base(strings.TrimSpace(m[2])) == assetName {
+				return makeChecksum(m[1], extAlgorithm)
+			}
+			continue
+		}
+
+		if m := bareChecksumRE.FindStringSubmatch(line); m != nil && perAsset {
+			return makeChecksum(m[1], extAlgorithm)
+		}
+	}
+
+	return nil, false
+}
+
+// verifies downloaded asset against sha256 of the pin, if the release is pinned
+func (r *GithubRelease) verifyPin(assetName string, downloadDir string) error {
+	if r.Pin == nil {
+		return nil
+	}
+
+	actual, err := hashFile(path.Join(downloadDir, assetName), "sha256")
+	if err != nil {
+		return err
+	}
+
+	pinOutput := map[string]string{
+		"status":   "verified",
+		"expected": r.Pin.Sha256,
+		"actual":   actual,
+	}
+	output.Output().Set("asset_pin", pinOutput)
+	if actual != strings.ToLower(r.Pin.Sha256) {
+		pinOutput["status"] = "mismatch"
+		return fmt.Errorf("asset %s of %s %s no longer matches locked sha256: expected %s, got %s",
+			assetName, r.Repository, r.Pin.Tag, r.Pin.Sha256, actual)
+	}
+
+	return nil
+}
+
+// verifies downloaded asset against the digest ('<algorithm>:<value>') reported by the Github releases API
+func (r *GithubRelease) verifyDigest(asset *selector.SelectorItem, downloadDir string) error {
+	digest := asset.GetPropStr("digest")
+	algorithm, expected, found := strings.Cut(digest, ":")
+	if !found {
+		output.Output().Set("asset_digest", map[string]string{"status": "unavailable"})
+		return nil
+	}
+	algorithm = strings.ToLower(algorithm)
+	expected = strings.ToLower(expected)
+
+	actual, err := hashFile(path.Join(downloadDir, asset.Name), algorithm)
+	if err != nil {
+		return err
+	}
+
+	digestOutput := map[string]string{
+		"status":    "verified",
+		"algorithm": algorithm,
+		"expected":  expected,
+		"actual":    actual,
+	}
+	output.Output().Set("asset_digest", digestOutput)
+	if actual != expected {
+		digestOutput["status"] = "mismatch"
+		return fmt.Errorf("downloaded asset %s does not match digest reported by Github: expected %s:%s, got %s:%s",
+			asset.Name, algorithm, expected, algorithm, actual)
+	}
+
+	r.digestVerified = true
+	return nil
+}
+
+// validates checksum asset regexp, so that an invalid '--checksum-regex' fails installation instead of disabling
+// verification
+func (r *GithubRelease) validateChecksumPattern() error {
+	if r.SkipChecksum {
+		return nil
+	}
+	if _, err := regexp.Compile(r.ChecksumPattern); err != nil {
+		return fmt.Errorf("invalid '--checksum-regex' %s: %v", r.ChecksumPattern, err)
+	}
+	return nil
+}
+
+func (r *GithubRelease) checksumUnavailable(reason error) error {
+	if r.RequireChecksum && !r.digestVerified {
+		return fmt.Errorf("checksum required but not available: %v", reason)
+	}
+
+	output.Output().Set("checksum", map[string]string{
+		"status": "unavailable",
+		"reason": reason.Error(),
+	})
+	return nil
+}
+
+// downloads the release checksum assets next to the downloaded asset and verifies asset contents against them
+func (r *GithubRelease) verifyChecksum(tag string, releaseId int, assetName string, downloadDir string) error {
+	if r.SkipChecksum {
+		output.Output().Set("checksum", map[string]string{"status": "skipped"})
+		return nil
+	}
+
+	checksumSelector, err := selector.ChecksumSelector(r.Client, r.Repository, releaseId, r.ChecksumPattern)
+	if err != nil {
+		return err
+	}
+	// the pattern is validated before installation, so selection only fails if no release asset matches
+	checksumAssets, err := checksumSelector.SelectItems()
+	if err != nil {
+		return r.checksumUnavailable(err)
+	}
+
+	var candidates []string
+	for _, checksumAsset := range checksumAssets {
+		if checksumAsset.Name == assetName || signatureRE.MatchString(checksumAsset.Name) {
+			continue
+		}
+		candidates = append(candidates, checksumAsset.Name)
+	}
+	// per-asset checksum files ('<asset>.sha256') take precedence over release-wide checksum lists
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return strings.HasPrefix(candidates[i], assetName+".") && !strings.HasPrefix(candidates[j], assetName+".")
+	})
+
+	for _, candidate := range candidates {
+		_, stdErr, err := gh.Exec("release", "download", tag,
+			"--repo", r.Repository, "--pattern", candidate, "--dir", downloadDir)
+		if err != nil {
+			return fmt.Errorf("failed to download checksum asset %s: %s", candidate, stdErr.String())
+		}
+
+		content, err := os.ReadFile(path.Join(downloadDir, candidate))
+		if err != nil {
+			return err
+		}
+
+		expected, found := findChecksum(content, candidate, assetName)
+		if !found {
+			continue
+		}
+
+		actual, err := hashFile(path.Join(downloadDir, assetName), expected.Algorithm)
+		if err != nil {
+			return err
+		}
+
+		checksumOutput := map[string]string{
+			"status":    "verified",
+			"source":    candidate,
+			"algorithm": expected.Algorithm,
+			"expected":  expected.Value,
+			"actual":    actual,
+		}
+		if actual != expected.Value {
+			checksumOutput["status"] = "mismatch"
+			output.Output().Set("checksum", checksumOutput)
+			return fmt.Errorf("%s checksum mismatch for %s: expected %s, got %s",
+				expected.Algorithm, assetName, expected.Value, actual)
+		}
+		output.Output().Set("checksum", checksumOutput)
+		return nil
+	}
+
+	return r.checksumUnavailable(fmt.Errorf("no checksum for %s found in release assets", assetName))
+}
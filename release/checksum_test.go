@@ -0,0 +1,52 @@
+package release
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindChecksum(t *testing.T) {
+	sha256 := strings.Repeat("a", 64)
+	sha512 := strings.Repeat("b", 128)
+	md5 := strings.Repeat("c", 32)
+
+	tests := []struct {
+		description  string
+		content      string
+		checksumName string
+		assetName    string
+		found        bool
+		algorithm    string
+		value        string
+	}{
+		{"gnu list", sha256 + "  tool_linux_amd64.tar.gz\n" + md5 + "  other.tar.gz\n", "checksums.txt",
+			"tool_linux_amd64.tar.gz", true, "sha256", sha256},
+		{"gnu binary mode", sha256 + " *tool.tar.gz\n", "SHA256SUMS", "tool.tar.gz", true, "sha256", sha256},
+		{"gnu path prefix", sha256 + "  ./dist/tool.tar.gz\n", "checksums.txt", "tool.tar.gz", true, "sha256", sha256},
+		{"gnu uppercase digest", strings.ToUpper(sha256) + "  tool.tar.gz\n", "checksums.txt", "tool.tar.gz", true,
+			"sha256", sha256},
+		{"gnu algorithm from extension", sha512 + "  tool.tar.gz\n", "tool.tar.gz.sha512", "tool.tar.gz", true,
+			"sha512", sha512},
+		{"bsd", "SHA512 (tool.tar.gz) = " + sha512 + "\n", "checksums", "tool.tar.gz", true, "sha512", sha512},
+		{"bsd other asset", "SHA512 (other.tar.gz) = " + sha512 + "\n", "checksums", "tool.tar.gz", false, "", ""},
+		{"per-asset bare digest", sha256 + "\n", "tool.tar.gz.sha256", "tool.tar.gz", true, "sha256", sha256},
+		{"bare digest of other asset", sha256 + "\n", "other.tar.gz.sha256", "tool.tar.gz", false, "", ""},
+		{"comments and blank lines", "# checksums\n\n" + sha256 + "  tool.tar.gz\n", "checksums.txt", "tool.tar.gz",
+			true, "sha256", sha256},
+		{"unknown digest length", strings.Repeat("d", 20) + "  tool.tar.gz\n", "checksums.txt", "tool.tar.gz", false,
+			"", ""},
+		{"asset not listed", sha256 + "  other.tar.gz\n", "checksums.txt", "tool.tar.gz", false, "", ""},
+	}
+
+	for _, test := range tests {
+		expected, found := findChecksum([]byte(test.content), test.checksumName, test.assetName)
+		if found != test.found {
+			t.Errorf("%s: found = %v, expected %v", test.description, found, test.found)
+			continue
+		}
+		if found && (expected.Algorithm != test.algorithm || expected.Value != test.value) {
+			t.Errorf("%s: checksum = %s:%s, expected %s:%s", test.description, expected.Algorithm, expected.Value,
+				test.algorithm, test.value)
+		}
+	}
+}
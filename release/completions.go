@@ -0,0 +1,134 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/store"
+)
+
+const completionTimeout = 5 * time.Second
+
+// completion subcommands probed, as used by cobra ('tool completion bash') and clap ('tool completions bash') tools
+var completionCommands = []string{"completion", "completions"}
+
+// shell completion script location under $XDG_DATA_HOME and a marker valid scripts contain
+var completionShells = map[string]struct {
+	destination func(command string) string
+	marker      string
+}{
+	"bash": {func(command string) string { return path.Join("bash-completion", "completions", command) }, "complete"},
+	"zsh":  {func(command string) string { return path.Join("zsh", "site-functions", "_"+command) }, "compdef"},
+	"fish": {func(command string) string { return path.Join("fish", "vendor_completions.d", command+".fish") }, "complete"},
+}
+
+// runs binary completion subcommand for shell with a timeout, in an empty temp directory and environment, so that
+// probing can not read or modify user configuration
+func probeCompletion(binaryPath string, shell string) ([]byte, error) {
+	sandboxDir, err := os.MkdirTemp("", "gh-install-completion-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(sandboxDir)
+
+	var lastErr error
+	for _, subcommand := range completionCommands {
+		ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+		cmd := exec.CommandContext(ctx, binaryPath, subcommand, shell)
+		cmd.Dir = sandboxDir
+		cmd.Env = []string{
+			"PATH=/usr/bin:/bin",
+			"HOME=" + sandboxDir,
+			"XDG_CONFIG_HOME=" + path.Join(sandboxDir, ".config"),
+			"XDG_DATA_HOME=" + path.Join(sandboxDir, ".local", "share"),
+			"XDG_CACHE_HOME=" + path.Join(sandboxDir, ".cache"),
+			"TMPDIR=" + sandboxDir,
+			"LANG=C",
+			"NO_COLOR=1",
+		}
+		cmd.WaitDelay = time.Second
+		script, err := cmd.Output()
+		cancel()
+
+		if err == nil && bytes.Contains(script, []byte(completionShells[shell].marker)) {
+			return script, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("'%s %s' output is not a %s completion script", subcommand, shell, shell)
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// generates shell completions for installed binaries by running their completion subcommand. Shells with
+// completion scripts shipped in the release archive are skipped. Binaries without a completion subcommand are
+// reported, but do not fail the installation
+func (r *GithubRelease) generateCompletions(receipt *Receipt) error {
+	if !r.Completions {
+		return nil
+	}
+	completionsOutput := make(map[string]string)
+	defer output.Output().Set("asset_generated_completions", completionsOutput)
+
+	if !r.TargetPlatform().IsHost() {
+		completionsOutput["status"] = "skipped, binaries are not built for the host"
+		return nil
+	}
+	dataHome := store.DataHome()
+	if dataHome == "" {
+		return fmt.Errorf("could not determine $XDG_DATA_HOME to install completions to")
+	}
+
+	recorded := make(map[string]bool)
+	for _, file := range receipt.Files {
+		recorded[file.Path] = true
+	}
+
+	var commands []string
+	for name := range r.installedNames {
+		commands = append(commands, name)
+	}
+	sort.Strings(commands)
+
+	for _, command := range commands {
+		command = strings.TrimSuffix(command, ".exe")
+		for shell, completionShell := range completionShells {
+			key := fmt.Sprintf("%s %s", command, shell)
+			destinationPath := path.Join(dataHome, completionShell.destination(command))
+			if recorded[destinationPath] {
+				completionsOutput[key] = fmt.Sprintf("%s (shipped)", destinationPath)
+				continue
+			}
+
+			script, err := probeCompletion(path.Join(r.InstallPath, command), shell)
+			if err != nil {
+				completionsOutput[key] = fmt.Sprintf("unavailable: %v", err)
+				continue
+			}
+
+			scriptPath := r.extraPath(dataHome, completionShell.destination(command))
+			if err = r.replacement.copyReader(bytes.NewReader(script), scriptPath, 0644); err != nil {
+				return err
+			}
+			if err = receipt.addFile(scriptPath); err != nil {
+				return err
+			}
+			if err = r.linkExtra(dataHome, completionShell.destination(command), receipt); err != nil {
+				return err
+			}
+			recorded[destinationPath] = true
+			completionsOutput[key] = destinationPath
+		}
+	}
+
+	return nil
+}
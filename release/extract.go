@@ -0,0 +1,229 @@
+package release
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maximum number of symbolic links followed to resolve an archive file
+const maxArchiveLinks = 40
+
+// archive file opened for extraction, with the metadata extraction preserves
+type archiveFile struct {
+	fs.File
+	path    string
+	mode    fs.FileMode
+	modTime time.Time
+	// target of a symbolic link as stored in the archive, archive path of the linked file for hard links
+	linkTarget string
+	hardLink   bool
+}
+
+func (f *archiveFile) isSymlink() bool {
+	return f.mode&fs.ModeSymlink != 0
+}
+
+// opens archive file. Symbolic link targets are read from tar headers, or from the file contents for zip archives
+func openArchiveFile(fileSystem fs.FS, archivePath string) (*archiveFile, error) {
+	file, err := fileSystem.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	opened := &archiveFile{
+		File:    file,
+		path:    archivePath,
+		mode:    fileInfo.Mode(),
+		modTime: fileInfo.ModTime(),
+	}
+	if header, ok := fileInfo.Sys().(*tar.Header); ok {
+		opened.hardLink = header.Typeflag == tar.TypeLink
+		if opened.isSymlink() || opened.hardLink {
+			opened.linkTarget = header.Linkname
+		}
+	} else if opened.isSymlink() {
+		target, err := io.ReadAll(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		opened.linkTarget = string(target)
+	}
+
+	return opened, nil
+}
+
+// archive path a symbolic or hard link points to. Links to absolute paths or outside of the archive are errors
+func archiveLinkPath(linkPath string, target string, hardLink bool) (string, error) {
+	resolved := path.Join(path.Dir(linkPath), target)
+	if hardLink {
+		resolved = path.Clean(strings.Trim(target, "/"))
+	}
+	if target == "" || path.IsAbs(target) && !hardLink || !fs.ValidPath(resolved) {
+		return "", fmt.Errorf("archive link %s points outside of the archive (%s)", linkPath, target)
+	}
+	return resolved, nil
+}
+
+// opens archive file, following symbolic and hard links to the file that holds its contents
+func openResolvedArchiveFile(fileSystem fs.FS, archivePath string) (*archiveFile, error) {
+	for links := 0; links <= maxArchiveLinks; links++ {
+		file, err := openArchiveFile(fileSystem, archivePath)
+		if err != nil {
+			return nil, err
+		}
+		if !file.isSymlink() && !file.hardLink {
+			return file, nil
+		}
+		file.Close()
+
+		archivePath, err = archiveLinkPath(file.path, file.linkTarget, file.hardLink)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("too many levels of links resolving archive file %s", archivePath)
+}
+
+// parses '--mode' octal permission bits
+func parseMode(mode string) (fs.FileMode, error) {
+	parsed, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || parsed > 0777 {
+		return 0, fmt.Errorf("invalid mode '%s', must be octal permission bits such as '0755'", mode)
+	}
+	return fs.FileMode(parsed), nil
+}
+
+// permission bits archive file is extracted with. Executable files get execute bits wherever they have read bits,
+// '--mode' overrides modes of installed binaries
+func (r *GithubRelease) extractMode(file *archiveFile, executable bool) (fs.FileMode, error) {
+	if executable && r.Mode != "" {
+		return parseMode(r.Mode)
+	}
+
+	mode := file.mode.Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+	if executable && mode&0111 == 0 {
+		mode |= (mode & 0444) >> 2
+	}
+	return mode, nil
+}
+
+// sets file modification time to the time recorded in the archive, if there is one
+func keepModTime(filePath string, modTime time.Time) error {
+	if modTime.IsZero() {
+		return nil
+	}
+	return os.Chtimes(filePath, modTime, modTime)
+}
+
+// extracts archive file to destinationPath and records it in receipt, keeping its mode and modification time.
+// Symbolic links are recreated with their archive target when links is set, replaced by the file they point to
+// otherwise. Executable files are extracted with execute bits
+func (r *GithubRelease) extractFile(fileSystem fs.FS, archivePath string, destinationPath string, executable bool,
+	links bool, receipt *Receipt) error {
+	source, err := openArchiveFile(fileSystem, archivePath)
+	if err != nil {
+		return err
+	}
+	if source.hardLink || (source.isSymlink() && !links) {
+		source.Close()
+		if source, err = openResolvedArchiveFile(fileSystem, archivePath); err != nil {
+			return err
+		}
+	}
+	defer source.Close()
+
+	if source.isSymlink() {
+		if err = os.MkdirAll(path.Dir(destinationPath), os.ModePerm); err != nil {
+			return err
+		}
+		if err = r.replacement.replaceLink(source.linkTarget, destinationPath); err != nil {
+			return err
+		}
+		return receipt.addFile(destinationPath)
+	}
+
+	mode, err := r.extractMode(source, executable)
+	if err != nil {
+		return err
+	}
+	if err = r.replacement.copyReader(source, destinationPath, mode); err != nil {
+		return err
+	}
+	if err = keepModTime(destinationPath, source.modTime); err != nil {
+		return err
+	}
+	return receipt.addFile(destinationPath)
+}
+
+// extracts every file under archive directory root into destinationDir, keeping their paths relative to root.
+// Symbolic links within the extracted tree are kept, links pointing out of it are errors. Root can also be a single
+// file, which is extracted with the contents of the file it links to. Archive paths in executables are extracted
+// as executable. Returns extracted archive paths by installed path
+func (r *GithubRelease) extractTree(fileSystem fs.FS, root string, destinationDir string,
+	executables map[string]bool, receipt *Receipt) (map[string]string, error) {
+	extracted := make(map[string]string)
+	err := fs.WalkDir(fileSystem, root, func(archivePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		relative := strings.TrimPrefix(strings.TrimPrefix(archivePath, root), "/")
+		if root == "." {
+			relative = archivePath
+		}
+		links := relative != ""
+		if relative == "" {
+			relative = path.Base(archivePath)
+		}
+
+		if links && d.Type()&fs.ModeSymlink != 0 {
+			if err = checkTreeLink(fileSystem, archivePath, relative); err != nil {
+				return err
+			}
+		}
+
+		destinationPath := path.Join(destinationDir, relative)
+		err = r.extractFile(fileSystem, archivePath, destinationPath, executables[archivePath], links, receipt)
+		if err != nil {
+			return err
+		}
+		extracted[destinationPath] = archivePath
+		return nil
+	})
+	return extracted, err
+}
+
+// verifies that symbolic link at relative path of an extracted tree points into the tree
+func checkTreeLink(fileSystem fs.FS, archivePath string, relative string) error {
+	link, err := openArchiveFile(fileSystem, archivePath)
+	if err != nil {
+		return err
+	}
+	link.Close()
+
+	target := path.Join(path.Dir(relative), link.linkTarget)
+	if path.IsAbs(link.linkTarget) || !fs.ValidPath(target) {
+		return fmt.Errorf("archive link %s points outside of the extracted directory (%s)", archivePath,
+			link.linkTarget)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package release
+
+import (
+	"archive/tar"
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/mholt/archiver/v4"
+)
+
+type testArchiveEntry struct {
+	header  tar.Header
+	content string
+}
+
+// writes entries to a tar archive and opens it the way release assets are opened
+func testArchiveFs(t *testing.T, entries []testArchiveEntry) fs.FS {
+	archivePath := path.Join(t.TempDir(), "tool.tar")
+	archiveFile, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := tar.NewWriter(archiveFile)
+	for _, entry := range entries {
+		header := entry.header
+		header.Size = int64(len(entry.content))
+		if header.Typeflag == 0 {
+			header.Typeflag = tar.TypeReg
+		}
+		if err = writer.WriteHeader(&header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = writer.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = archiveFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fileSystem, err := archiver.FileSystem(context.TODO(), archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fileSystem
+}
+
+func TestArchiveLinkPath(t *testing.T) {
+	tests := []struct {
+		linkPath string
+		target   string
+		hardLink bool
+		expected string
+		valid    bool
+	}{
+		{"tool-1.0/bin/tool", "tool-1.0.1", false, "tool-1.0/bin/tool-1.0.1", true},
+		{"tool-1.0/bin/tool", "../libexec/tool", false, "tool-1.0/libexec/tool", true},
+		{"tool-1.0/bin/tool", "../../tool", false, "tool", true},
+		{"tool-1.0/bin/tool", "../../../tool", false, "", false},
+		{"tool-1.0/bin/tool", "/usr/bin/tool", false, "", false},
+		{"tool-1.0/bin/tool", "", false, "", false},
+		// hard link targets are archive paths, not relative to the link
+		{"tool-1.0/bin/tool", "tool-1.0/libexec/tool", true, "tool-1.0/libexec/tool", true},
+		{"tool-1.0/bin/tool", "/tool-1.0/libexec/tool", true, "tool-1.0/libexec/tool", true},
+		{"tool-1.0/bin/tool", "../tool", true, "", false},
+		{"tool-1.0/bin/tool", "", true, "", false},
+	}
+
+	for _, test := range tests {
+		resolved, err := archiveLinkPath(test.linkPath, test.target, test.hardLink)
+		if (err == nil) != test.valid {
+			t.Errorf("archiveLinkPath(%q, %q, %v) error = %v, expected valid %v", test.linkPath, test.target,
+				test.hardLink, err, test.valid)
+			continue
+		}
+		if resolved != test.expected {
+			t.Errorf("archiveLinkPath(%q, %q, %v) = %q, expected %q", test.linkPath, test.target, test.hardLink,
+				resolved, test.expected)
+		}
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		mode     string
+		expected fs.FileMode
+		valid    bool
+	}{
+		{"0755", 0755, true},
+		{"755", 0755, true},
+		{"0700", 0700, true},
+		{"0", 0, true},
+		{"0777", 0777, true},
+		{"01777", 0, false},
+		{"0789", 0, false},
+		{"rwxr-xr-x", 0, false},
+		{"", 0, false},
+	}
+
+	for _, test := range tests {
+		mode, err := parseMode(test.mode)
+		if (err == nil) != test.valid {
+			t.Errorf("parseMode(%q) error = %v, expected valid %v", test.mode, err, test.valid)
+			continue
+		}
+		if mode != test.expected {
+			t.Errorf("parseMode(%q) = %o, expected %o", test.mode, mode, test.expected)
+		}
+	}
+}
+
+func TestExtractMode(t *testing.T) {
+	tests := []struct {
+		mode       fs.FileMode
+		executable bool
+		override   string
+		expected   fs.FileMode
+		valid      bool
+	}{
+		{0644, false, "", 0644, true},
+		{0755, false, "", 0755, true},
+		{0644, true, "", 0755, true},
+		{0640, true, "", 0750, true},
+		{0600, true, "", 0700, true},
+		{0750, true, "", 0750, true},
+		// zero-mode archive entries
+		{0, false, "", 0644, true},
+		{0, true, "", 0755, true},
+		// '--mode' only applies to executables
+		{0644, true, "0700", 0700, true},
+		{0, true, "0711", 0711, true},
+		{0644, false, "0700", 0644, true},
+		{0644, true, "0999", 0, false},
+	}
+
+	for _, test := range tests {
+		r := &GithubRelease{Spec: Spec{Mode: test.override}}
+		mode, err := r.extractMode(&archiveFile{mode: test.mode}, test.executable)
+		if (err == nil) != test.valid {
+			t.Errorf("extractMode(%o, %v) with mode %q error = %v, expected valid %v", test.mode, test.executable,
+				test.override, err, test.valid)
+			continue
+		}
+		if mode != test.expected {
+			t.Errorf("extractMode(%o, %v) with mode %q = %o, expected %o", test.mode, test.executable,
+				test.override, mode, test.expected)
+		}
+	}
+}
+
+func TestCheckTreeLink(t *testing.T) {
+	fileSystem := testArchiveFs(t, []testArchiveEntry{
+		{tar.Header{Name: "tool-1.0/lib/libtool.so.1", Mode: 0644}, "library"},
+		{tar.Header{Name: "tool-1.0/lib/libtool.so", Typeflag: tar.TypeSymlink, Linkname: "libtool.so.1"}, ""},
+		{tar.Header{Name: "tool-1.0/bin/tool", Typeflag: tar.TypeSymlink, Linkname: "../lib/tool"}, ""},
+		{tar.Header{Name: "tool-1.0/bin/escape", Typeflag: tar.TypeSymlink, Linkname: "../../etc/passwd"}, ""},
+		{tar.Header{Name: "tool-1.0/bin/absolute", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}, ""},
+	})
+
+	tests := []struct {
+		archivePath string
+		relative    string
+		valid       bool
+	}{
+		{"tool-1.0/lib/libtool.so", "lib/libtool.so", true},
+		{"tool-1.0/bin/tool", "bin/tool", true},
+		// the same link points out of a tree rooted at its directory
+		{"tool-1.0/bin/tool", "tool", false},
+		{"tool-1.0/bin/escape", "bin/escape", false},
+		{"tool-1.0/bin/absolute", "bin/absolute", false},
+	}
+
+	for _, test := range tests {
+		if err := checkTreeLink(fileSystem, test.archivePath, test.relative); (err == nil) != test.valid {
+			t.Errorf("checkTreeLink(%q, %q) error = %v, expected valid %v", test.archivePath, test.relative, err,
+				test.valid)
+		}
+	}
+}
+
+func TestExtractTree(t *testing.T) {
+	fileSystem := testArchiveFs(t, []testArchiveEntry{
+		{tar.Header{Name: "tool-1.0/bin/tool", Mode: 0}, "binary"},
+		{tar.Header{Name: "tool-1.0/lib/libtool.so.1", Mode: 0}, "library"},
+		{tar.Header{Name: "tool-1.0/lib/libtool.so", Typeflag: tar.TypeSymlink, Linkname: "libtool.so.1"}, ""},
+		{tar.Header{Name: "tool-1.0/bin/tool-server", Typeflag: tar.TypeLink, Linkname: "tool-1.0/bin/tool"}, ""},
+	})
+
+	tests := []struct {
+		override string
+		modes    map[string]fs.FileMode
+	}{
+		{"", map[string]fs.FileMode{"bin/tool": 0755, "bin/tool-server": 0755, "lib/libtool.so.1": 0644}},
+		{"0700", map[string]fs.FileMode{"bin/tool": 0700, "bin/tool-server": 0700, "lib/libtool.so.1": 0644}},
+	}
+
+	for _, test := range tests {
+		destinationDir := t.TempDir()
+		r := &GithubRelease{Spec: Spec{Mode: test.override}}
+		executables := map[string]bool{"tool-1.0/bin/tool": true, "tool-1.0/bin/tool-server": true}
+		extracted, err := r.extractTree(fileSystem, "tool-1.0", destinationDir, executables, &Receipt{})
+		if err != nil {
+			t.Fatalf("extractTree with mode %q failed: %v", test.override, err)
+		}
+		if len(extracted) != 4 {
+			t.Errorf("extractTree with mode %q extracted %d files, expected 4", test.override, len(extracted))
+		}
+
+		for relative, expected := range test.modes {
+			info, err := os.Lstat(path.Join(destinationDir, relative))
+			if err != nil {
+				t.Errorf("%s was not extracted: %v", relative, err)
+				continue
+			}
+			if info.Mode() != expected {
+				t.Errorf("%s extracted with mode %s and mode %q, expected %s", relative, info.Mode(),
+					test.override, expected)
+			}
+		}
+
+		// hard links are extracted as copies of the file they link
+		if content, err := os.ReadFile(path.Join(destinationDir, "bin/tool-server")); err != nil ||
+			string(content) != "binary" {
+			t.Errorf("hard link extracted with %q (%v), expected %q", content, err, "binary")
+		}
+		if target, err := os.Readlink(path.Join(destinationDir, "lib/libtool.so")); err != nil ||
+			target != "libtool.so.1" {
+			t.Errorf("symbolic link extracted pointing to %q (%v), expected %q", target, err, "libtool.so.1")
+		}
+	}
+}
+
+func TestExtractTreeRejectsEscapingLinks(t *testing.T) {
+	for _, target := range []string{"../../outside", "/etc/passwd"} {
+		fileSystem := testArchiveFs(t, []testArchiveEntry{
+			{tar.Header{Name: "tool-1.0/bin/tool", Mode: 0755}, "binary"},
+			{tar.Header{Name: "tool-1.0/bin/link", Typeflag: tar.TypeSymlink, Linkname: target}, ""},
+		})
+
+		destinationDir := t.TempDir()
+		r := &GithubRelease{}
+		if _, err := r.extractTree(fileSystem, "tool-1.0", destinationDir, nil, &Receipt{}); err == nil {
+			t.Errorf("extractTree extracted link to %q, expected an error", target)
+		}
+		if _, err := os.Lstat(path.Join(destinationDir, "bin/link")); !os.IsNotExist(err) {
+			t.Errorf("link to %q was extracted", target)
+		}
+	}
+}
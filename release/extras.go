@@ -0,0 +1,179 @@
+package release
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/selector"
+	"github.com/maratoid/gh-install/store"
+)
+
+const (
+	extraBashCompletion = "bash-completion"
+	extraZshCompletion  = "zsh-completion"
+	extraFishCompletion = "fish-completion"
+	extraManPage        = "man"
+	extraDoc            = "doc"
+)
+
+var (
+	manPageRE = regexp.MustCompile(`\.([1-9])[a-z]*(?:\.gz)?$`)
+	// shared libraries have man page like extensions: 'libfoo.so.1'
+	sharedLibraryRE = regexp.MustCompile(`\.so(?:\.\d+)+$`)
+	manDirs         = []string{"man", "man1", "man2", "man3", "man4", "man5", "man6", "man7", "man8", "man9"}
+)
+
+// reports whether any directory of archive path is named one of names
+func inDir(dirs []string, names ...string) bool {
+	for _, dir := range dirs {
+		for _, name := range names {
+			if strings.EqualFold(dir, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reports whether archive file is a man page: a file in a man directory, or a '<name>.<section>[.gz]' file at
+// the top of the archive or in a doc directory named after the repository or one of the binaries
+func isManPage(lowerBase string, dirs []string, names []string) bool {
+	if !manPageRE.MatchString(lowerBase) || sharedLibraryRE.MatchString(lowerBase) {
+		return false
+	}
+	if inDir(dirs, manDirs...) {
+		return true
+	}
+	if len(dirs) > 2 && !inDir(dirs, "doc", "docs") {
+		return false
+	}
+	pageName := manPageRE.ReplaceAllString(lowerBase, "")
+	for _, name := range names {
+		if strings.EqualFold(pageName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifies well-known non-binary archive files (shell completions, man pages, documentation) and returns
+// their installation path relative to $XDG_DATA_HOME. Kind is empty for other files. Binary names are the names
+// of binaries selected for installation
+func classifyExtra(archivePath string, repo string, binaryNames []string) (string, string) {
+	base := path.Base(archivePath)
+	lowerBase := strings.ToLower(base)
+	dirs := strings.Split(path.Dir(archivePath), "/")
+	completionDir := inDir(dirs, "completion", "completions", "complete", "autocomplete", "contrib") ||
+		strings.Contains(lowerBase, "complet")
+
+	switch {
+	// man pages are often shipped in doc directories
+	case isManPage(lowerBase, dirs, append([]string{strings.Split(repo, "/")[1]}, binaryNames...)):
+		section := manPageRE.FindStringSubmatch(lowerBase)[1]
+		return extraManPage, path.Join("man", "man"+section, base)
+	case inDir(dirs, "doc", "docs"):
+		docDir := 0
+		for index, dir := range dirs {
+			if strings.EqualFold(dir, "doc") || strings.EqualFold(dir, "docs") {
+				docDir = index
+				break
+			}
+		}
+		relative := path.Join(append(dirs[docDir+1:], base)...)
+		return extraDoc, path.Join("doc", strings.Split(repo, "/")[1], relative)
+	case strings.HasSuffix(lowerBase, ".fish") && (completionDir || inDir(dirs, "fish")):
+		return extraFishCompletion, path.Join("fish", "vendor_completions.d", base)
+	case strings.HasSuffix(lowerBase, ".zsh") && (completionDir || inDir(dirs, "zsh")):
+		return extraZshCompletion, path.Join("zsh", "site-functions", "_"+strings.TrimPrefix(strings.TrimSuffix(base, path.Ext(base)), "_"))
+	case strings.HasPrefix(base, "_") && path.Ext(base) == "" && (completionDir || inDir(dirs, "zsh")):
+		return extraZshCompletion, path.Join("zsh", "site-functions", base)
+	case (strings.HasSuffix(lowerBase, ".bash") || strings.HasSuffix(lowerBase, ".bash-completion")) &&
+		(completionDir || inDir(dirs, "bash")):
+		command := strings.TrimSuffix(strings.TrimSuffix(base, path.Ext(base)), "-completion")
+		return extraBashCompletion, path.Join("bash-completion", "completions", command)
+	case completionDir && inDir(dirs, "bash") && !strings.HasPrefix(base, "."):
+		return extraBashCompletion, path.Join("bash-completion", "completions", base)
+	}
+	return "", ""
+}
+
+// path extra file destination (relative to $XDG_DATA_HOME) is written to: the 'share' directory of the store
+// version directory if the release is installed to the store, so that switching versions switches extras too
+func (r *GithubRelease) extraPath(dataHome string, destination string) string {
+	if r.storeDir == "" {
+		return path.Join(dataHome, destination)
+	}
+	return path.Join(r.storeDir, "share", destination)
+}
+
+// links extra file destination written to the store version directory into $XDG_DATA_HOME
+func (r *GithubRelease) linkExtra(dataHome string, destination string, receipt *Receipt) error {
+	if r.storeDir == "" {
+		return nil
+	}
+
+	linkPath := path.Join(dataHome, destination)
+	if err := os.MkdirAll(path.Dir(linkPath), os.ModePerm); err != nil {
+		return err
+	}
+	if err := r.replaceLink(r.extraPath(dataHome, destination), linkPath); err != nil {
+		return err
+	}
+	return receipt.addFile(linkPath)
+}
+
+// installs shell completions, man pages and documentation shipped in the release archive under $XDG_DATA_HOME.
+// Files installed as binaries are skipped
+func (r *GithubRelease) installExtras(binaries []*selector.SelectorItem, receipt *Receipt) error {
+	var fileSystem fs.FS
+	installedBinaries := make(map[string]bool)
+	var binaryNames []string
+	for _, binary := range binaries {
+		if binary.GetPropBool("archive") {
+			fileSystem = binary.GetPropFs("fs")
+			installedBinaries[binary.GetPropStr("path")] = true
+		}
+		binaryNames = append(binaryNames, strings.TrimSuffix(binary.Name, ".exe"))
+	}
+	if fileSystem == nil || r.NoExtras {
+		return nil
+	}
+
+	dataHome := store.DataHome()
+	if dataHome == "" {
+		return fmt.Errorf("could not determine $XDG_DATA_HOME to install completions, man pages and docs to")
+	}
+
+	extrasOutput := make(map[string]string)
+	defer output.Output().Set("asset_installed_extras", extrasOutput)
+
+	return fs.WalkDir(fileSystem, ".", func(archivePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || installedBinaries[archivePath] {
+			return nil
+		}
+
+		kind, destination := classifyExtra(archivePath, r.Repository, binaryNames)
+		if kind == "" {
+			return nil
+		}
+		destinationPath := path.Join(dataHome, destination)
+
+		if err = r.extractFile(fileSystem, archivePath, r.extraPath(dataHome, destination), false, false,
+			receipt); err != nil {
+			return err
+		}
+		if err = r.linkExtra(dataHome, destination, receipt); err != nil {
+			return err
+		}
+		extrasOutput[archivePath] = fmt.Sprintf("%s (%s)", destinationPath, kind)
+		return nil
+	})
+}
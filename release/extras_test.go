@@ -0,0 +1,40 @@
+package release
+
+import "testing"
+
+func TestClassifyExtra(t *testing.T) {
+	tests := []struct {
+		archivePath string
+		kind        string
+		destination string
+	}{
+		{"tool.1", extraManPage, "man/man1/tool.1"},
+		{"tool-1.0/tool.1.gz", extraManPage, "man/man1/tool.1.gz"},
+		{"tool-1.0/helper.8", extraManPage, "man/man8/helper.8"},
+		{"tool-1.0/share/man/man5/tool.conf.5", extraManPage, "man/man5/tool.conf.5"},
+		{"man/tool-sub.1", extraManPage, "man/man1/tool-sub.1"},
+		{"tool-1.0/doc/tool.1", extraManPage, "man/man1/tool.1"},
+		{"tool-1.0/share/doc/helper.1.gz", extraManPage, "man/man1/helper.1.gz"},
+		{"tool-1.0/doc/other.1", extraDoc, "doc/tool/other.1"},
+		{"tool-1.0/lib/libfoo.so.1", "", ""},
+		{"tool-1.0/man/libfoo.so.1", "", ""},
+		{"tool-1.0/tool-1.2.3", "", ""},
+		{"README.1st", "", ""},
+		{"tool-1.0/other.1", "", ""},
+		{"a/b/c/tool.1", "", ""},
+		{"tool-1.0/docs/guide.md", extraDoc, "doc/tool/guide.md"},
+		{"completions/tool.fish", extraFishCompletion, "fish/vendor_completions.d/tool.fish"},
+		{"completions/tool.zsh", extraZshCompletion, "zsh/site-functions/_tool"},
+		{"completions/_tool", extraZshCompletion, "zsh/site-functions/_tool"},
+		{"completions/tool.bash", extraBashCompletion, "bash-completion/completions/tool"},
+		{"tool-1.0/tool", "", ""},
+	}
+
+	for _, test := range tests {
+		kind, destination := classifyExtra(test.archivePath, "owner/tool", []string{"helper"})
+		if kind != test.kind || destination != test.destination {
+			t.Errorf("classifyExtra(%q) = (%q, %q), expected (%q, %q)", test.archivePath, kind, destination,
+				test.kind, test.destination)
+		}
+	}
+}
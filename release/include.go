@@ -0,0 +1,127 @@
+package release
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/selector"
+)
+
+// '--include' mapping rule: archive files and directories matching pattern are installed to destination
+type includeRule struct {
+	pattern     string
+	destination string
+}
+
+// parses 'src-glob:dest-dir' mapping rule
+func parseIncludeRule(rule string) (includeRule, error) {
+	pattern, destination, found := strings.Cut(rule, ":")
+	pattern, destination = strings.Trim(pattern, "/"), strings.TrimSpace(destination)
+	if !found || pattern == "" || destination == "" {
+		return includeRule{}, fmt.Errorf("invalid include rule '%s', must be in 'src-glob:dest-dir' format", rule)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return includeRule{}, fmt.Errorf("invalid include rule '%s': %v", rule, err)
+	}
+	return includeRule{pattern: pattern, destination: destination}, nil
+}
+
+func (r *GithubRelease) includeRules() ([]includeRule, error) {
+	var rules []includeRule
+	for _, rule := range r.Includes {
+		parsed, err := parseIncludeRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, parsed)
+	}
+	return rules, nil
+}
+
+// destination directory of rule: '~/' is expanded, relative directories are relative to the store version
+// directory if the release is installed to the store, to installation directory otherwise
+func (r *GithubRelease) includeDir(rule includeRule) (string, error) {
+	destination := rule.destination
+	if destination == "~" || strings.HasPrefix(destination, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		destination = path.Join(homeDir, strings.TrimPrefix(destination, "~"))
+	}
+	if path.IsAbs(destination) {
+		return path.Clean(destination), nil
+	}
+	if r.storeDir != "" {
+		return path.Join(r.storeDir, destination), nil
+	}
+	return path.Join(r.InstallPath, destination), nil
+}
+
+// file system of the release archive binaries were selected from, nil if the asset is not an archive
+func archiveFs(binaries []*selector.SelectorItem) fs.FS {
+	for _, binary := range binaries {
+		if binary.GetPropBool("archive") {
+			return binary.GetPropFs("fs")
+		}
+	}
+	return nil
+}
+
+// installs archive files and directories matching '--include' rules. Matching directories are installed with
+// their contents into the rule destination, matching files are installed into it by name
+func (r *GithubRelease) installIncludes(fileSystem fs.FS, receipt *Receipt) error {
+	rules, err := r.includeRules()
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	if fileSystem == nil {
+		return fmt.Errorf("'--include' rules can only be used with archive release assets")
+	}
+
+	includesOutput := make(map[string]string)
+	defer output.Output().Set("asset_included_files", includesOutput)
+
+	for _, rule := range rules {
+		destinationDir, err := r.includeDir(rule)
+		if err != nil {
+			return err
+		}
+
+		matched := false
+		err = fs.WalkDir(fileSystem, ".", func(archivePath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if match, _ := path.Match(rule.pattern, archivePath); !match || archivePath == "." {
+				return nil
+			}
+			matched = true
+
+			extracted, err := r.extractTree(fileSystem, archivePath, destinationDir, nil, receipt)
+			if err != nil {
+				return err
+			}
+			for installedPath, extractedPath := range extracted {
+				includesOutput[extractedPath] = installedPath
+			}
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if !matched {
+			return fmt.Errorf("include rule '%s:%s' matches no file of the release archive", rule.pattern,
+				rule.destination)
+		}
+	}
+
+	return nil
+}
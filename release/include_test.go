@@ -0,0 +1,32 @@
+package release
+
+import "testing"
+
+func TestParseIncludeRule(t *testing.T) {
+	tests := []struct {
+		rule     string
+		expected includeRule
+		valid    bool
+	}{
+		{"share/*:share", includeRule{pattern: "share/*", destination: "share"}, true},
+		{"/tool-1.0/lib/:~/lib/tool", includeRule{pattern: "tool-1.0/lib", destination: "~/lib/tool"}, true},
+		{"LICENSE: doc ", includeRule{pattern: "LICENSE", destination: "doc"}, true},
+		{"*/completions:/usr/local/share", includeRule{pattern: "*/completions", destination: "/usr/local/share"}, true},
+		{"share", includeRule{}, false},
+		{":share", includeRule{}, false},
+		{"/:share", includeRule{}, false},
+		{"share:", includeRule{}, false},
+		{"share/[:share", includeRule{}, false},
+	}
+
+	for _, test := range tests {
+		rule, err := parseIncludeRule(test.rule)
+		if (err == nil) != test.valid {
+			t.Errorf("parseIncludeRule(%q) error = %v, expected valid %v", test.rule, err, test.valid)
+			continue
+		}
+		if rule != test.expected {
+			t.Errorf("parseIncludeRule(%q) = %+v, expected %+v", test.rule, rule, test.expected)
+		}
+	}
+}
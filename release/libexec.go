@@ -0,0 +1,99 @@
+package release
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/selector"
+)
+
+// archive directory holding the tool the binaries belong to: directory of the binaries, or its parent if binaries
+// are in a 'bin' directory ('tool-1.0/bin/tool' -> 'tool-1.0'). Common directory of all binaries if they differ
+func libexecRoot(binaryPaths []string) string {
+	var root []string
+	for index, binaryPath := range binaryPaths {
+		dir := path.Dir(binaryPath)
+		if base := path.Base(dir); base == "bin" || base == "sbin" {
+			dir = path.Dir(dir)
+		}
+		parts := strings.Split(dir, "/")
+		if index == 0 {
+			root = parts
+			continue
+		}
+
+		common := 0
+		for common < len(root) && common < len(parts) && root[common] == parts[common] {
+			common++
+		}
+		root = root[:common]
+	}
+
+	if len(root) == 0 {
+		return "."
+	}
+	return path.Join(root...)
+}
+
+// extracts the archive directory tree of the selected binaries into the store version directory, keeping its
+// layout so that binaries find files they ship with. Returns the extracted archive root
+func (r *GithubRelease) extractLibexec(binaries []*selector.SelectorItem, receipt *Receipt) (string, error) {
+	fileSystem := archiveFs(binaries)
+	if fileSystem == nil {
+		return "", fmt.Errorf("'--libexec' can only be used with archive release assets")
+	}
+
+	var binaryPaths []string
+	entryPoints := make(map[string]bool)
+	for _, binary := range binaries {
+		binaryPaths = append(binaryPaths, binary.GetPropStr("path"))
+		entryPoints[binary.GetPropStr("path")] = true
+	}
+	root := libexecRoot(binaryPaths)
+
+	extracted, err := r.extractTree(fileSystem, root, r.storeDir, entryPoints, receipt)
+	if err != nil {
+		return "", err
+	}
+	output.Output().Set("libexec", map[string]interface{}{
+		"archive_root":    root,
+		"extracted_files": len(extracted),
+	})
+	return root, nil
+}
+
+// links archived binary extracted from archive root into installation directory. Returns installed link path
+func (r *GithubRelease) linkLibexecBinary(fileSystem fs.FS, binaryPath string, root string) ([]string, error) {
+	sourceFile, err := openResolvedArchiveFile(fileSystem, binaryPath)
+	if err != nil {
+		return nil, err
+	}
+	defer sourceFile.Close()
+
+	inspectReader, _, err := readerAt(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+	err = r.checkBinary(path.Base(binaryPath), inspectReader)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := r.installName(path.Base(binaryPath), false)
+	if err != nil {
+		return nil, err
+	}
+	destinationPath, err := r.reserveName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	relative := binaryPath
+	if root != "." {
+		relative = strings.TrimPrefix(binaryPath, root+"/")
+	}
+	return []string{destinationPath}, r.replaceLink(path.Join(r.storeDir, relative), destinationPath)
+}
@@ -0,0 +1,27 @@
+package release
+
+import "testing"
+
+func TestLibexecRoot(t *testing.T) {
+	tests := []struct {
+		binaryPaths []string
+		expected    string
+	}{
+		{[]string{"tool-1.0/bin/tool"}, "tool-1.0"},
+		{[]string{"tool-1.0/sbin/toold"}, "tool-1.0"},
+		{[]string{"tool-1.0/tool"}, "tool-1.0"},
+		{[]string{"bin/tool"}, "."},
+		{[]string{"tool"}, "."},
+		{[]string{"tool-1.0/bin/tool", "tool-1.0/bin/tool-server"}, "tool-1.0"},
+		{[]string{"tool-1.0/bin/tool", "tool-1.0/sbin/toold"}, "tool-1.0"},
+		{[]string{"tool-1.0/bin/tool", "tool-1.0/libexec/tool/helper"}, "tool-1.0"},
+		{[]string{"tool-1.0/bin/tool", "other-2.0/bin/other"}, "."},
+		{[]string{"dist/tool-1.0/bin/tool", "dist/other-2.0/bin/other"}, "dist"},
+	}
+
+	for _, test := range tests {
+		if root := libexecRoot(test.binaryPaths); root != test.expected {
+			t.Errorf("libexecRoot(%v) = %q, expected %q", test.binaryPaths, root, test.expected)
+		}
+	}
+}
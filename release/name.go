@@ -0,0 +1,90 @@
+package release
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/maratoid/gh-install/platform"
+)
+
+var nameFuncs = template.FuncMap{
+	"trimPlatform": platform.TrimPlatform,
+	"trimVersion":  platform.TrimVersion,
+	"lower":        strings.ToLower,
+}
+
+// values available to '--as' name templates
+type nameData struct {
+	Owner  string
+	Repo   string
+	Binary string
+	Tag    string
+	OS     string
+	Arch   string
+}
+
+func isNameTemplate(name string) bool {
+	return strings.Contains(name, "{{")
+}
+
+// validates '--as' for the binaries selected for installation: plain names can only name a single binary
+func (r *GithubRelease) validateInstallName(binaryCount int) error {
+	if r.InstallName == "" {
+		return nil
+	}
+	if !isNameTemplate(r.InstallName) {
+		if binaryCount > 1 {
+			return fmt.Errorf("'--as %s' can only name a single binary, %d binaries were selected (use a name template)",
+				r.InstallName, binaryCount)
+		}
+		return nil
+	}
+
+	_, err := template.New("as").Funcs(nameFuncs).Parse(r.InstallName)
+	if err != nil {
+		return fmt.Errorf("invalid '--as' name template: %v", err)
+	}
+	return nil
+}
+
+// installed name of binary: '--as' name or template if set, binary name without version and platform suffixes
+// for bare binary assets, archived binary name otherwise
+func (r *GithubRelease) installName(binaryName string, bare bool) (string, error) {
+	var name string
+	switch {
+	case r.InstallName == "" && bare:
+		name = platform.TrimPlatform(binaryName)
+	case r.InstallName == "":
+		name = binaryName
+	case !isNameTemplate(r.InstallName):
+		name = r.InstallName
+	default:
+		nameTemplate, err := template.New("as").Funcs(nameFuncs).Parse(r.InstallName)
+		if err != nil {
+			return "", fmt.Errorf("invalid '--as' name template: %v", err)
+		}
+
+		target := r.TargetPlatform()
+		owner, repo, _ := strings.Cut(r.Repository, "/")
+		var rendered strings.Builder
+		err = nameTemplate.Execute(&rendered, nameData{
+			Owner:  owner,
+			Repo:   repo,
+			Binary: binaryName,
+			Tag:    r.installTag,
+			OS:     target.OS,
+			Arch:   target.Arch,
+		})
+		if err != nil {
+			return "", fmt.Errorf("could not render '--as' name template: %v", err)
+		}
+		name = strings.TrimSpace(rendered.String())
+	}
+
+	if name == "" || name == "." || name == ".." || path.Base(name) != name {
+		return "", fmt.Errorf("invalid installed name '%s' for binary %s", name, binaryName)
+	}
+	return name, nil
+}
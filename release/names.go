@@ -0,0 +1,46 @@
+package release
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
+
+// list of names that also unmarshals from a single name, so that manifests and registry entries written with
+// a single 'binary' keep working
+type NameList []string
+
+func (n *NameList) UnmarshalJSON(content []byte) error {
+	var name string
+	if err := json.Unmarshal(content, &name); err == nil {
+		*n = nil
+		if name != "" {
+			*n = NameList{name}
+		}
+		return nil
+	}
+
+	var names []string
+	if err := json.Unmarshal(content, &names); err != nil {
+		return err
+	}
+	*n = names
+	return nil
+}
+
+func (n *NameList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*n = nil
+		if value.Value != "" {
+			*n = NameList{value.Value}
+		}
+		return nil
+	}
+
+	var names []string
+	if err := value.Decode(&names); err != nil {
+		return err
+	}
+	*n = names
+	return nil
+}
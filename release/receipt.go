@@ -0,0 +1,105 @@
+package release
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/maratoid/gh-install/selector"
+)
+
+// file created by an installation
+type InstalledFile struct {
+	Path   string      `json:"path"`
+	Sha256 string      `json:"sha256"`
+	Mode   fs.FileMode `json:"mode"`
+	Target string      `json:"target,omitempty"`
+}
+
+// record of a completed installation: the spec it was requested with and what it resolved to
+type Receipt struct {
+	Spec        Spec            `json:"spec"`
+	Tag         string          `json:"tag"`
+	ReleaseId   int             `json:"release_id"`
+	Asset       string          `json:"asset"`
+	AssetSha256 string          `json:"asset_sha256,omitempty"`
+	Files       []InstalledFile `json:"files"`
+	InstalledAt time.Time       `json:"installed_at"`
+}
+
+func (r *Receipt) addFile(filePath string) error {
+	installedFile, err := statFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	// files written more than once by an installation ('--include' rules overlapping binaries) are recorded once
+	for index, file := range r.Files {
+		if file.Path == filePath {
+			r.Files[index] = installedFile
+			return nil
+		}
+	}
+	r.Files = append(r.Files, installedFile)
+	return nil
+}
+
+// installed file record of filePath as it is on disk
+func statFile(filePath string) (InstalledFile, error) {
+	fileInfo, err := os.Lstat(filePath)
+	if err != nil {
+		return InstalledFile{}, err
+	}
+
+	installedFile := InstalledFile{
+		Path: filePath,
+		Mode: fileInfo.Mode(),
+	}
+	// symbolic links are recorded by target, links extracted from archives may point to files extracted later
+	if fileInfo.Mode()&fs.ModeSymlink != 0 {
+		installedFile.Target, err = os.Readlink(filePath)
+	} else {
+		installedFile.Sha256, err = hashFile(filePath, "sha256")
+	}
+	return installedFile, err
+}
+
+func (r *Receipt) String() string {
+	var paths []string
+	for _, file := range r.Files {
+		paths = append(paths, file.Path)
+	}
+
+	return fmt.Sprintf("%s %s [%s] %s", r.Tag, r.Asset, strings.Join(paths, ", "),
+		r.InstalledAt.Format(time.RFC3339))
+}
+
+func selectionPattern(items []*selector.SelectorItem) string {
+	var names []string
+	for _, item := range items {
+		names = append(names, regexp.QuoteMeta(item.Name))
+	}
+
+	return fmt.Sprintf("^(?:%s)$", strings.Join(names, "|"))
+}
+
+// reports whether file contents (or symbolic link target) changed since it was installed
+func (f InstalledFile) Modified() (bool, error) {
+	if f.Target != "" {
+		target, err := os.Readlink(f.Path)
+		if os.IsNotExist(err) {
+			return false, err
+		}
+		return err != nil || target != f.Target, nil
+	}
+
+	sha, err := hashFile(f.Path, "sha256")
+	if err != nil {
+		return false, err
+	}
+
+	return sha != f.Sha256, nil
+}
@@ -7,107 +7,291 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/cli/go-gh/v2"
 	"github.com/cli/go-gh/v2/pkg/api"
 	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/platform"
 	"github.com/maratoid/gh-install/selector"
+	"github.com/maratoid/gh-install/store"
 )
 
 type IRelease interface {
 	Install() error
+	Resolve() (*Pin, error)
+	Receipt() *Receipt
+}
+
+// parameters selecting the release, asset and binaries of a repository to install
+type Spec struct {
+	Repository         string   `json:"repository" yaml:"repo"`
+	ReleaseVersion     string   `json:"release_version" yaml:"tag,omitempty"`
+	ReleasePattern     string   `json:"release_pattern,omitempty" yaml:"tag-regex,omitempty"`
+	Channel            string   `json:"channel,omitempty" yaml:"channel,omitempty"`
+	Prerelease         bool     `json:"prerelease,omitempty" yaml:"prerelease,omitempty"`
+	IncludeDrafts      bool     `json:"include_drafts,omitempty" yaml:"include-drafts,omitempty"`
+	InstallPath        string   `json:"install_path" yaml:"path,omitempty"`
+	AssetName          string   `json:"asset_name,omitempty" yaml:"download,omitempty"`
+	AssetPattern       string   `json:"asset_pattern,omitempty" yaml:"download-regex,omitempty"`
+	AssetBinaryNames   NameList `json:"binary_name,omitempty" yaml:"binary,omitempty"`
+	AssetBinaryPattern string   `json:"binary_pattern,omitempty" yaml:"binary-regex,omitempty"`
+	ChecksumPattern    string   `json:"checksum_pattern,omitempty" yaml:"checksum-regex,omitempty"`
+	RequireChecksum    bool     `json:"require_checksum,omitempty" yaml:"require-checksum,omitempty"`
+	SkipChecksum       bool     `json:"skip_checksum,omitempty" yaml:"skip-checksum,omitempty"`
+	OS                 string   `json:"os,omitempty" yaml:"os,omitempty"`
+	Arch               string   `json:"arch,omitempty" yaml:"arch,omitempty"`
+	Libc               string   `json:"libc,omitempty" yaml:"libc,omitempty"`
+	Force              bool     `json:"force,omitempty" yaml:"force,omitempty"`
+	Strict             bool     `json:"strict,omitempty" yaml:"strict,omitempty"`
+	Store              bool     `json:"store,omitempty" yaml:"store,omitempty"`
+	InstallName        string   `json:"install_name,omitempty" yaml:"as,omitempty"`
+	MultipleBinaries   bool     `json:"multiple_binaries,omitempty" yaml:"multiple,omitempty"`
+	AllBinaries        bool     `json:"all_binaries,omitempty" yaml:"all-binaries,omitempty"`
+	NoExtras           bool     `json:"no_extras,omitempty" yaml:"no-extras,omitempty"`
+	Completions        bool     `json:"completions,omitempty" yaml:"completions,omitempty"`
+	Includes           NameList `json:"includes,omitempty" yaml:"include,omitempty"`
+	Libexec            bool     `json:"libexec,omitempty" yaml:"libexec,omitempty"`
+	Mode               string   `json:"mode,omitempty" yaml:"mode,omitempty"`
+}
+
+// concrete release asset a spec resolves to
+type Pin struct {
+	Repository string `json:"repository" yaml:"repo"`
+	Tag        string `json:"tag" yaml:"tag"`
+	ReleaseId  int    `json:"release_id" yaml:"release-id"`
+	Asset      string `json:"asset" yaml:"asset"`
+	Sha256     string `json:"sha256" yaml:"sha256"`
+	// platform the asset was selected for, unset for pins of earlier lock files
+	Platform *platform.Platform `json:"platform,omitempty" yaml:"platform,omitempty"`
+}
+
+// releases the spec may select: '--prerelease' allows pre-releases in addition to stable releases
+func (s Spec) ReleasePolicy() selector.ReleasePolicy {
+	channel := s.Channel
+	if s.Prerelease && (channel == "" || channel == selector.GH_INSTALL_CHANNEL_STABLE) {
+		channel = selector.GH_INSTALL_CHANNEL_ANY
+	}
+
+	return selector.ReleasePolicy{
+		Channel:       channel,
+		IncludeDrafts: s.IncludeDrafts,
+	}
+}
+
+// reports whether installing s and other installs the same assets and binaries in the same way, once both
+// resolve to the same release. Release selection fields are not compared
+func (s Spec) InstallsLike(other Spec) bool {
+	normalize := func(spec Spec) Spec {
+		spec.ReleaseVersion, spec.ReleasePattern, spec.Channel = "", "", ""
+		spec.Prerelease, spec.IncludeDrafts = false, false
+		spec.InstallPath = path.Clean(spec.InstallPath)
+		// libexec installations are always store installations
+		spec.Store = spec.Store || spec.Libexec
+		if len(spec.AssetBinaryNames) == 0 {
+			spec.AssetBinaryNames = nil
+		}
+		if len(spec.Includes) == 0 {
+			spec.Includes = nil
+		}
+		return spec
+	}
+	return reflect.DeepEqual(normalize(s), normalize(other))
+}
+
+// platform release assets are selected for: the host, with os, arch and libc overridden by '--os', '--arch'
+// and '--libc'
+func (s Spec) TargetPlatform() platform.Platform {
+	return platform.Target(s.OS, s.Arch, s.Libc)
 }
 
 type GithubRelease struct {
-	Repository         string
-	Interactive        bool
-	ReleaseVersion     string
-	InstallPath        string
-	AssetName          string
-	AssetPattern       string
-	AssetBinaryName    string
-	AssetBinaryPattern string
-	Client             *api.RESTClient
+	Spec
+	Pin            *Pin
+	Interactive    bool
+	Client         *api.RESTClient
+	receipt        *Receipt
+	digestVerified bool
+	binaryChecks   map[string]map[string]string
+	// store version directory binaries are installed to, empty if not installed to the store
+	storeDir       string
+	installTag     string
+	installedNames map[string]bool
+	// installed names of archived binaries, by archive path
+	archivedNames map[string]string
+	replacement
 }
 
-func MakeGithubRelease(repo string, ver string, dest string,
-	assetName string, assetMatcher string, binName string, binMatcher string,
-	cli *api.RESTClient, interactive bool) IRelease {
+func MakeGithubRelease(spec Spec, cli *api.RESTClient, interactive bool) IRelease {
+	return &GithubRelease{
+		Spec:        spec,
+		Interactive: interactive,
+		Client:      cli,
+	}
+}
 
+// release that installs exactly the asset of pin, failing if asset contents do not match the pinned sha256
+func MakePinnedGithubRelease(spec Spec, pin *Pin, cli *api.RESTClient) IRelease {
 	return &GithubRelease{
-		Repository:         repo,
-		Interactive:        interactive,
-		ReleaseVersion:     ver,
-		InstallPath:        dest,
-		AssetName:          assetName,
-		AssetPattern:       assetMatcher,
-		AssetBinaryName:    binName,
-		AssetBinaryPattern: binMatcher,
-		Client:             cli,
+		Spec:   spec,
+		Pin:    pin,
+		Client: cli,
 	}
 }
 
-func (r *GithubRelease) installArchivedBinary(fileSystem fs.FS, binaryPath string) error {
-	sourceFile, err := fileSystem.Open(binaryPath)
+func (r *GithubRelease) Receipt() *Receipt {
+	return r.receipt
+}
+
+func (r *GithubRelease) installArchivedBinary(fileSystem fs.FS, binaryPath string) ([]string, error) {
+	name, err := r.installName(path.Base(binaryPath), false)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer sourceFile.Close()
 
-	destinationPath := path.Join(r.InstallPath, path.Base(binaryPath))
-	destinationFile, err := os.Create(destinationPath)
+	source, err := openArchiveFile(fileSystem, binaryPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer destinationFile.Close()
+	defer func() { source.Close() }()
 
-	_, err = io.Copy(destinationFile, sourceFile)
-	if err != nil {
-		return err
+	if source.isSymlink() || source.hardLink {
+		linkedPath, err := archiveLinkPath(binaryPath, source.linkTarget, source.hardLink)
+		if err != nil {
+			return nil, err
+		}
+		// aliases of installed binaries are installed as links to them
+		if linkedName, found := r.archivedNames[linkedPath]; found && source.isSymlink() {
+			return r.installLink(linkedName, name)
+		}
+
+		source.Close()
+		if source, err = openResolvedArchiveFile(fileSystem, binaryPath); err != nil {
+			return nil, err
+		}
 	}
 
-	err = os.Chmod(destinationPath, 0755)
+	inspectReader, contents, err := readerAt(source)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	err = r.checkBinary(path.Base(binaryPath), inspectReader)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	mode, err := r.extractMode(source, true)
+	if err != nil {
+		return nil, err
+	}
+	installedPaths, err := r.installFile(contents, name, mode)
+	if err != nil {
+		return nil, err
+	}
+	if r.archivedNames == nil {
+		r.archivedNames = make(map[string]string)
+	}
+	r.archivedNames[binaryPath] = name
+	return installedPaths, keepModTime(installedPaths[0], source.modTime)
 }
 
-func (r *GithubRelease) installBinary(binaryPath string) error {
+func (r *GithubRelease) installBinary(binaryPath string) ([]string, error) {
 	sourceStat, err := os.Stat(binaryPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if !sourceStat.Mode().IsRegular() {
-		return fmt.Errorf("%s is not a regular file", binaryPath)
+		return nil, fmt.Errorf("%s is not a regular file", binaryPath)
 	}
 
 	source, err := os.Open(binaryPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer source.Close()
 
-	destinationPath := path.Join(r.InstallPath, path.Base(binaryPath))
-	destination, err := os.Create(destinationPath)
+	err = r.checkBinary(path.Base(binaryPath), source)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer destination.Close()
 
-	_, err = io.Copy(destination, source)
+	name, err := r.installName(path.Base(binaryPath), true)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	var mode fs.FileMode = 0755
+	if r.Mode != "" {
+		if mode, err = parseMode(r.Mode); err != nil {
+			return nil, err
+		}
+	}
+	return r.installFile(source, name, mode)
+}
+
+// returns installation directory path of installed binary name, failing if another binary is installed as name
+func (r *GithubRelease) reserveName(name string) (string, error) {
+	destinationPath := path.Join(r.InstallPath, name)
+	if r.installedNames[name] {
+		return "", fmt.Errorf("more than one selected binary would be installed as %s", destinationPath)
+	}
+	if r.installedNames == nil {
+		r.installedNames = make(map[string]bool)
+	}
+	r.installedNames[name] = true
+	return destinationPath, nil
+}
 
-	err = os.Chmod(destinationPath, 0755)
+// installs symbolic link name pointing to target into installation directory or, if the release is installed to
+// the store, into the store version directory with a symbolic link to it in installation directory. Returns
+// installed paths
+func (r *GithubRelease) installLink(target string, name string) ([]string, error) {
+	destinationPath, err := r.reserveName(name)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	if r.storeDir == "" {
+		return []string{destinationPath}, r.replaceLink(target, destinationPath)
+	}
+
+	err = os.MkdirAll(r.storeDir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+	storePath := path.Join(r.storeDir, name)
+	if err = r.replaceLink(target, storePath); err != nil {
+		return nil, err
+	}
+
+	return []string{storePath, destinationPath}, r.replaceLink(storePath, destinationPath)
+}
+
+// installs file into installation directory or, if the release is installed to the store, into the store
+// version directory with a symbolic link in installation directory. Returns installed paths
+func (r *GithubRelease) installFile(source io.Reader, name string, mode fs.FileMode) ([]string, error) {
+	destinationPath, err := r.reserveName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.storeDir == "" {
+		return []string{destinationPath}, r.replaceFile(source, destinationPath, mode)
+	}
+
+	err = os.MkdirAll(r.storeDir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+	storePath := path.Join(r.storeDir, name)
+	if err = r.replaceFile(source, storePath, mode); err != nil {
+		return nil, err
+	}
+
+	return []string{storePath, destinationPath}, r.replaceLink(storePath, destinationPath)
 }
 
 func (r *GithubRelease) installDeb(binaryPath string) error {
@@ -128,50 +312,164 @@ func (r *GithubRelease) installRpm(binaryPath string) error {
 	return nil
 }
 
+// selects release and release asset to install, from pin if the release is pinned
+func (r *GithubRelease) selectAsset() (*selector.SelectorItem, *selector.SelectorItem, error) {
+	version, versionPattern, interactive := r.ReleaseVersion, r.ReleasePattern, r.Interactive
+	assetName, assetPattern := r.AssetName, r.AssetPattern
+	if r.Pin != nil {
+		if r.Pin.Platform != nil && !r.Pin.Platform.Compatible(r.TargetPlatform()) {
+			return nil, nil, fmt.Errorf("asset %s of %s is locked for %s, not %s, run 'gh install lock' for %s",
+				r.Pin.Asset, r.Repository, r.Pin.Platform, r.TargetPlatform(), r.TargetPlatform())
+		}
+		version, versionPattern, interactive = r.Pin.Tag, "", false
+		assetName, assetPattern = r.Pin.Asset, ""
+	}
+
+	releaseSelector, err := selector.ReleaseSelector(r.Client, r.Repository,
+		version, versionPattern, r.ReleasePolicy(), interactive)
+	if err != nil {
+		return nil, nil, err
+	}
+	releases, err := releaseSelector.SelectItems()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	assetSelector, err := selector.AssetSelector(r.Client, r.Repository, releases[0].GetPropInt("id"),
+		assetName, assetPattern, r.TargetPlatform(), interactive)
+	if err != nil {
+		return nil, nil, err
+	}
+	assets, err := assetSelector.SelectItems()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return releases[0], assets[0], nil
+}
+
+func (r *GithubRelease) downloadAsset(tag string, assetName string, downloadDir string) error {
+	stdOut, stdErr, err := gh.Exec("release", "download", tag,
+		"--repo", r.Repository, "--pattern", assetName, "--dir", downloadDir)
+	if err != nil {
+		output.Output().Set("gh_stderr", stdErr.String())
+		return fmt.Errorf("failed to run gh command: %s", stdErr.String())
+	}
+	output.Output().Set("gh_stdout", stdOut.String())
+
+	return nil
+}
+
+// resolves release and asset the spec selects, without installing it
+func (r *GithubRelease) Resolve() (*Pin, error) {
+	releaseItem, asset, err := r.selectAsset()
+	if err != nil {
+		return nil, err
+	}
+
+	target := r.TargetPlatform()
+	pin := &Pin{
+		Repository: r.Repository,
+		Tag:        releaseItem.Name,
+		ReleaseId:  releaseItem.GetPropInt("id"),
+		Asset:      asset.Name,
+		Platform:   &target,
+	}
+
+	if algorithm, digest, found := strings.Cut(asset.GetPropStr("digest"), ":"); found && strings.EqualFold(algorithm, "sha256") {
+		pin.Sha256 = strings.ToLower(digest)
+		return pin, nil
+	}
+
+	// no digest reported by Github, asset has to be downloaded and hashed
+	downloadDir, err := os.MkdirTemp("", "*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(downloadDir)
+
+	err = r.downloadAsset(pin.Tag, pin.Asset, downloadDir)
+	if err != nil {
+		return nil, err
+	}
+	pin.Sha256, err = hashFile(path.Join(downloadDir, pin.Asset), "sha256")
+	if err != nil {
+		return nil, err
+	}
+
+	return pin, nil
+}
+
 func (r *GithubRelease) Install() error {
 	var err error
 	defer func() {
 		if err != nil {
+			r.restoreReplaced()
 			output.Output().Set("error", err)
 		}
 	}()
 	output.Output().Set("target_repository", r.Repository)
 	output.Output().Set("install_dir", r.InstallPath)
+	output.Output().Set("target_platform", r.TargetPlatform())
 
-	releaseSelector, err := selector.ReleaseSelector(r.Client, r.Repository, r.ReleaseVersion, r.Interactive)
+	if _, err = r.includeRules(); err != nil {
+		return err
+	}
+	if err = r.validateChecksumPattern(); err != nil {
+		return err
+	}
+	if r.Mode != "" {
+		if _, err = parseMode(r.Mode); err != nil {
+			return err
+		}
+	}
+	if r.Libexec {
+		// binaries run from the archive tree extracted to the store version directory
+		r.Store = true
+	}
+
+	releaseItem, asset, err := r.selectAsset()
 	if err != nil {
 		return err
 	}
-	releases, err := releaseSelector.SelectItems()
+	r.installTag = releaseItem.Name
+	if r.Store {
+		r.storeDir = store.VersionDir(r.Repository, releaseItem.Name)
+		output.Output().Set("store_dir", r.storeDir)
+	}
+
+	downloadDir, err := os.MkdirTemp("", "*")
 	if err != nil {
 		return err
 	}
+	output.Output().Set("download_dir", downloadDir)
 
-	assetSelector, err := selector.AssetSelector(r.Client, r.Repository, releases[0].GetPropInt("id"), r.AssetName, r.AssetPattern, r.Interactive)
+	err = r.downloadAsset(releaseItem.Name, asset.Name, downloadDir)
 	if err != nil {
 		return err
 	}
-	assets, err := assetSelector.SelectItems()
+
+	err = r.verifyPin(asset.Name, downloadDir)
 	if err != nil {
 		return err
 	}
 
-	downloadDir, err := os.MkdirTemp("", "*")
+	err = r.verifyDigest(asset, downloadDir)
 	if err != nil {
 		return err
 	}
-	output.Output().Set("download_dir", downloadDir)
 
-	stdOut, stdErr, err := gh.Exec("release", "download", releases[0].Name,
-		"--repo", r.Repository, "--pattern", assets[0].Name, "--dir", downloadDir)
+	err = r.verifyChecksum(releaseItem.Name, releaseItem.GetPropInt("id"), asset.Name, downloadDir)
 	if err != nil {
-		output.Output().Set("gh_stderr", stdErr.String())
-		return fmt.Errorf("failed to run gh command: %s", stdErr.String())
+		return err
 	}
-	output.Output().Set("gh_stdout", stdOut.String())
 
-	binarySelector, err := selector.BinarySelector(path.Join(downloadDir,
-		assets[0].Name), r.AssetBinaryName, r.AssetBinaryPattern, r.Interactive)
+	binaryPattern := r.AssetBinaryPattern
+	if r.AllBinaries {
+		binaryPattern = ".*"
+	}
+	binarySelector, err := selector.BinarySelector(path.Join(downloadDir, asset.Name), r.AssetBinaryNames,
+		binaryPattern, r.MultipleBinaries || r.AllBinaries, r.Interactive)
 	if err != nil {
 		return err
 	}
@@ -179,30 +477,112 @@ func (r *GithubRelease) Install() error {
 	if err != nil {
 		return err
 	}
+	if r.AllBinaries && !r.Interactive {
+		binaries, err = executableBinaries(binaries)
+		if err != nil {
+			return err
+		}
+	}
+	err = r.validateInstallName(len(binaries))
+	if err != nil {
+		return err
+	}
+	// binaries linking to other selected binaries are installed as links after them
+	sort.SliceStable(binaries, func(i, j int) bool {
+		return !isSymlinkItem(binaries[i]) && isSymlinkItem(binaries[j])
+	})
 
-	binariesOutput := make(map[string]string)
+	receipt := &Receipt{
+		Spec:      r.Spec,
+		Tag:       releaseItem.Name,
+		ReleaseId: releaseItem.GetPropInt("id"),
+		Asset:     asset.Name,
+	}
+	receipt.AssetSha256, err = hashFile(path.Join(downloadDir, asset.Name), "sha256")
+	if err != nil {
+		return err
+	}
+	var libexecRoot string
+	if r.Libexec {
+		libexecRoot, err = r.extractLibexec(binaries, receipt)
+		if err != nil {
+			return err
+		}
+	}
+	binariesOutput := make(map[string]map[string]string)
 	for _, binary := range binaries {
+		var installedPaths []string
+		binaryOutput := map[string]string{"name": binary.Name}
 		if binary.GetPropBool("archive") {
-			binariesOutput[binary.Name] = "compressed"
-			err = r.installArchivedBinary(binary.GetPropFs("fs"), binary.GetPropStr("path"))
+			binaryOutput["type"] = "compressed"
+			binariesOutput[binary.GetPropStr("path")] = binaryOutput
+			if r.Libexec {
+				installedPaths, err = r.linkLibexecBinary(binary.GetPropFs("fs"), binary.GetPropStr("path"), libexecRoot)
+			} else {
+				installedPaths, err = r.installArchivedBinary(binary.GetPropFs("fs"), binary.GetPropStr("path"))
+			}
 		} else {
+			binariesOutput[binary.Name] = binaryOutput
 			if binary.GetPropStr("binType") == "deb" {
-				binariesOutput[binary.Name] = "deb"
+				binaryOutput["type"] = "deb"
 				err = r.installDeb(binary.GetPropStr("path"))
 			} else if binary.GetPropStr("binType") == "rpm" {
-				binariesOutput[binary.Name] = "rpm"
+				binaryOutput["type"] = "rpm"
 				err = r.installRpm(binary.GetPropStr("path"))
 			} else {
-				binariesOutput[binary.Name] = "binary"
-				err = r.installBinary(binary.GetPropStr("path"))
+				binaryOutput["type"] = "binary"
+				installedPaths, err = r.installBinary(binary.GetPropStr("path"))
+			}
+		}
+		for _, installedPath := range installedPaths {
+			if err == nil {
+				err = receipt.addFile(installedPath)
 			}
 		}
 		if err != nil {
+			binaryOutput["status"] = "failed"
+			binaryOutput["error"] = err.Error()
 			output.Output().Set("asset_installed_binaries", binariesOutput)
+			output.Output().Set("asset_binary_checks", r.binaryChecks)
+			return err
+		}
+		binaryOutput["status"] = "installed"
+		if len(installedPaths) > 0 {
+			binaryOutput["installed_path"] = installedPaths[len(installedPaths)-1]
+		}
+	}
+
+	err = r.installIncludes(archiveFs(binaries), receipt)
+	if err != nil {
+		return err
+	}
+
+	err = r.installExtras(binaries, receipt)
+	if err != nil {
+		return err
+	}
+
+	err = r.generateCompletions(receipt)
+	if err != nil {
+		return err
+	}
+
+	if r.Interactive {
+		// interactive selections are recorded so that the same binaries are picked on later installs
+		receipt.Spec.AssetBinaryNames, receipt.Spec.AllBinaries = nil, false
+		receipt.Spec.AssetBinaryPattern = selectionPattern(binaries)
+		receipt.Spec.MultipleBinaries = len(binaries) > 1
+	}
+	receipt.InstalledAt = time.Now()
+	if r.storeDir != "" {
+		if err = r.saveStoreReceipt(receipt); err != nil {
 			return err
 		}
 	}
+	r.receipt = receipt
+	r.commitReplaced()
 
 	output.Output().Set("asset_installed_binaries", binariesOutput)
+	output.Output().Set("asset_binary_checks", r.binaryChecks)
 	return nil
 }
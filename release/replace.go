@@ -0,0 +1,174 @@
+package release
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+)
+
+const backupSuffix = ".gh-install-backup"
+
+// file replaced by an installation: backupPath holds its previous contents, empty if the file did not exist
+type replacedFile struct {
+	path       string
+	backupPath string
+}
+
+// files replaced so far, restored if the installation fails
+type replacement struct {
+	replaced []replacedFile
+}
+
+// atomically replaces destinationPath with contents of source: contents are written to a temp file in the same
+// directory, synced, given mode and renamed into place, so that the destination is never half-written and running
+// binaries can be replaced. The previous file is kept as a backup until the replacement is committed
+func (r *replacement) replaceFile(source io.Reader, destinationPath string, mode fs.FileMode) error {
+	tempFile, err := os.CreateTemp(path.Dir(destinationPath), "."+path.Base(destinationPath)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err = io.Copy(tempFile, source); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err = tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err = tempFile.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tempFile.Name(), mode); err != nil {
+		return err
+	}
+
+	return r.swap(tempFile.Name(), destinationPath)
+}
+
+// atomically points symbolic link linkPath to target, replacing whatever linkPath is
+func (r *replacement) replaceLink(target string, linkPath string) error {
+	tempFile, err := os.CreateTemp(path.Dir(linkPath), "."+path.Base(linkPath)+".*")
+	if err != nil {
+		return err
+	}
+	tempFile.Close()
+	os.Remove(tempFile.Name())
+
+	if err = os.Symlink(target, tempFile.Name()); err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	return r.swap(tempFile.Name(), linkPath)
+}
+
+// renames tempPath over destinationPath, keeping a backup of the destination. Destinations replaced more than once
+// keep the backup of their original file
+func (r *replacement) swap(tempPath string, destinationPath string) error {
+	for _, replaced := range r.replaced {
+		if replaced.path == destinationPath {
+			return os.Rename(tempPath, destinationPath)
+		}
+	}
+
+	replaced := replacedFile{path: destinationPath}
+	if _, err := os.Lstat(destinationPath); err == nil {
+		replaced.backupPath = destinationPath + backupSuffix
+		os.Remove(replaced.backupPath)
+		// hard link keeps the destination in place until it is replaced by rename
+		if err = os.Link(destinationPath, replaced.backupPath); err != nil {
+			if err = os.Rename(destinationPath, replaced.backupPath); err != nil {
+				return err
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	r.replaced = append(r.replaced, replaced)
+
+	return os.Rename(tempPath, destinationPath)
+}
+
+// removes backups of replaced files once the installation succeeded
+func (r *replacement) commitReplaced() {
+	for _, replaced := range r.replaced {
+		if replaced.backupPath != "" {
+			os.Remove(replaced.backupPath)
+		}
+	}
+	r.replaced = nil
+}
+
+// restores replaced files from their backups and removes files the failed installation created
+func (r *replacement) restoreReplaced() {
+	for index := len(r.replaced) - 1; index >= 0; index-- {
+		replaced := r.replaced[index]
+		if replaced.backupPath == "" {
+			os.Remove(replaced.path)
+			continue
+		}
+		os.Rename(replaced.backupPath, replaced.path)
+	}
+	r.replaced = nil
+}
+
+// restores files of receipt from copies kept in kept (by installed file path). Files are verified against their
+// recorded sha256 and replaced atomically; either all files are restored or none is. Symbolic links are restored
+// from their recorded targets
+func RestoreFiles(receipt *Receipt, kept map[string]string) error {
+	var restore replacement
+	for _, file := range receipt.Files {
+		if file.Target != "" {
+			if err := restore.replaceLink(file.Target, file.Path); err != nil {
+				restore.restoreReplaced()
+				return err
+			}
+			continue
+		}
+
+		keptPath, found := kept[file.Path]
+		if !found {
+			restore.restoreReplaced()
+			return fmt.Errorf("no copy of %s was kept", file.Path)
+		}
+
+		sha, err := hashFile(keptPath, "sha256")
+		if err == nil && sha != file.Sha256 {
+			err = fmt.Errorf("kept copy %s of %s does not match its recorded sha256", keptPath, file.Path)
+		}
+		if err != nil {
+			restore.restoreReplaced()
+			return err
+		}
+
+		if err = restore.copyFile(keptPath, file.Path, file.Mode.Perm()); err != nil {
+			restore.restoreReplaced()
+			return err
+		}
+	}
+
+	restore.commitReplaced()
+	return nil
+}
+
+func (r *replacement) copyFile(sourcePath string, destinationPath string, mode fs.FileMode) error {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	return r.copyReader(source, destinationPath, mode)
+}
+
+// replaces destinationPath with contents of source, creating its directory
+func (r *replacement) copyReader(source io.Reader, destinationPath string, mode fs.FileMode) error {
+	if err := os.MkdirAll(path.Dir(destinationPath), os.ModePerm); err != nil {
+		return err
+	}
+	return r.replaceFile(source, destinationPath, mode)
+}
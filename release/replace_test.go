@@ -0,0 +1,57 @@
+package release
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestReplaceFileTwiceRestoresOriginal(t *testing.T) {
+	destinationPath := path.Join(t.TempDir(), "tool")
+	if err := os.WriteFile(destinationPath, []byte("original"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var replace replacement
+	for _, content := range []string{"binary", "include"} {
+		if err := replace.replaceFile(strings.NewReader(content), destinationPath, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(replace.replaced) != 1 {
+		t.Fatalf("replaced %d files, expected 1", len(replace.replaced))
+	}
+
+	replace.restoreReplaced()
+	content, err := os.ReadFile(destinationPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "original" {
+		t.Errorf("restored %q, expected %q", content, "original")
+	}
+	if _, err = os.Lstat(destinationPath + backupSuffix); !os.IsNotExist(err) {
+		t.Errorf("backup %s was not removed", destinationPath+backupSuffix)
+	}
+}
+
+func TestReceiptRecordsFileOnce(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "tool")
+	receipt := &Receipt{}
+	for _, content := range []string{"binary", "include"} {
+		if err := os.WriteFile(filePath, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := receipt.addFile(filePath); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(receipt.Files) != 1 {
+		t.Fatalf("recorded %d files, expected 1", len(receipt.Files))
+	}
+	if modified, err := receipt.Files[0].Modified(); err != nil || modified {
+		t.Errorf("recorded file does not match last written contents")
+	}
+}
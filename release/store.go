@@ -0,0 +1,69 @@
+package release
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/maratoid/gh-install/store"
+)
+
+func (r *GithubRelease) saveStoreReceipt(receipt *Receipt) error {
+	content, err := json.MarshalIndent(receipt, "", "  ")
+	if err != nil {
+		return err
+	}
+	return r.replaceFile(bytes.NewReader(content), path.Join(r.storeDir, store.GH_INSTALL_RECEIPT_NAME), 0644)
+}
+
+// receipt of release tag of repo installed to the store
+func LoadStoreReceipt(repo string, tag string) (*Receipt, error) {
+	content, err := os.ReadFile(path.Join(store.VersionDir(repo, tag), store.GH_INSTALL_RECEIPT_NAME))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s %s is not in the store", repo, tag)
+		}
+		return nil, err
+	}
+
+	receipt := &Receipt{}
+	if err = json.Unmarshal(content, receipt); err != nil {
+		return nil, fmt.Errorf("could not parse store receipt of %s %s: %v", repo, tag, err)
+	}
+	return receipt, nil
+}
+
+// points symbolic links of receipt back to its store version. Either all links are replaced or none is. Files
+// outside of the store written by versions installed before extras were kept in the store are not switched, their
+// records are updated from disk so that they are not reported as modified
+func ActivateStoreVersion(receipt *Receipt) error {
+	versionDir := store.VersionDir(receipt.Spec.Repository, receipt.Tag) + "/"
+	var activate replacement
+	for index, file := range receipt.Files {
+		// links within the version directory are part of the stored version
+		if strings.HasPrefix(file.Path, versionDir) {
+			continue
+		}
+		if file.Target == "" {
+			if onDisk, err := statFile(file.Path); err == nil {
+				receipt.Files[index] = onDisk
+			}
+			continue
+		}
+		if _, err := os.Stat(file.Target); err != nil {
+			activate.restoreReplaced()
+			return fmt.Errorf("store file %s of %s %s is missing: %v", file.Target, receipt.Spec.Repository,
+				receipt.Tag, err)
+		}
+		if err := activate.replaceLink(file.Target, file.Path); err != nil {
+			activate.restoreReplaced()
+			return err
+		}
+	}
+
+	activate.commitReplaced()
+	return nil
+}
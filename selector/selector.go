@@ -3,9 +3,11 @@ package selector
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"regexp"
@@ -15,6 +17,8 @@ import (
 	"github.com/lithammer/fuzzysearch/fuzzy"
 	"github.com/manifoldco/promptui"
 	"github.com/maratoid/gh-install/output"
+	"github.com/maratoid/gh-install/platform"
+	"github.com/maratoid/gh-install/semver"
 	"github.com/mholt/archiver/v4"
 )
 
@@ -26,11 +30,16 @@ type Selector struct {
 	Kind     string
 	Items    []*SelectorItem
 	Name     string
+	Names    []string
 	Matcher  string
 	Multiple bool
 }
 
 func (s *Selector) SelectItems() ([]*SelectorItem, error) {
+	if len(s.Names) > 0 {
+		return s.selectNamedItems()
+	}
+
 	var selectedItems []*SelectorItem
 	var matches []string
 
@@ -82,6 +91,62 @@ func (s *Selector) SelectItems() ([]*SelectorItem, error) {
 	return selectedItems, nil
 }
 
+// selects single item with the best platform score. Items scoring below zero are never selected
+type ScoringSelector struct {
+	Kind   string
+	Items  []*SelectorItem
+	Target platform.Platform
+}
+
+func (s *ScoringSelector) SelectItems() ([]*SelectorItem, error) {
+	outputKey := strings.ReplaceAll(s.Kind, " ", "_")
+	output.Output().Set(fmt.Sprintf("%s_%s", outputKey, "platform"), s.Target.String())
+
+	var best []*SelectorItem
+	bestScore := -1
+	scores := make(map[string]int)
+	for _, item := range s.Items {
+		score, reasons := s.Target.ScoreAsset(item.Name)
+		scores[item.Name] = score
+		item.SetProp("score", score)
+		item.SetProp("scoreReasons", reasons)
+
+		switch {
+		case score < 0 || score < bestScore:
+			continue
+		case score > bestScore:
+			best, bestScore = nil, score
+		}
+		best = append(best, item)
+	}
+	output.Output().Set(fmt.Sprintf("%s_%s", outputKey, "scores"), scores)
+
+	var matches []string
+	for _, item := range best {
+		matches = append(matches, item.Name)
+	}
+	if matches == nil {
+		matches = make([]string, 0, 1)
+	}
+	output.Output().Set(fmt.Sprintf("%s_%s", outputKey, "matches"), matches)
+
+	if len(best) == 0 {
+		return nil, fmt.Errorf("no %s suitable for %s found", s.Kind, s.Target)
+	}
+	if len(best) > 1 {
+		return nil, fmt.Errorf("more than one item suitable for %s found for %s (%s), select one with name or regexp",
+			s.Target, s.Kind, strings.Join(matches, ", "))
+	}
+
+	best[0].Selected = true
+	output.Output().Set(fmt.Sprintf("%s_%s", outputKey, "selection_reasons"), best[0].GetProp("scoreReasons"))
+	return best, nil
+}
+
+// marks pre-release and draft release items in interactive selection
+const itemMarks = `{{ if index .Properties "prerelease" }} {{ "(pre-release)" | yellow }}{{ end }}` +
+	`{{ if index .Properties "draft" }} {{ "(draft)" | faint }}{{ end }}`
+
 type InteractiveSelector struct {
 	Kind         string
 	LastSelected int
@@ -114,15 +179,15 @@ func (s *InteractiveSelector) SelectItems() ([]*SelectorItem, error) {
 			Label: `{{ if .Selected }}
 						✔
 					{{ end }} {{ .Name }}`,
-			Active:   "→ {{ if .Selected }}✔ {{ end }}{{ .Name | cyan }}",
-			Inactive: "{{ if .Selected }}✔ {{ end }}{{ .Name }}",
+			Active:   "→ {{ if .Selected }}✔ {{ end }}{{ .Name | cyan }}" + itemMarks,
+			Inactive: "{{ if .Selected }}✔ {{ end }}{{ .Name }}" + itemMarks,
 		}
 	} else {
 		// Define promptui template
 		templates = &promptui.SelectTemplates{
 			Help:     "Use <Enter> to select, '/' to search and '↓ ↑ → ←' to navigate.",
-			Active:   "→ {{ .Name | cyan }}",
-			Inactive: "{{ .Name }}",
+			Active:   "→ {{ .Name | cyan }}" + itemMarks,
+			Inactive: "{{ .Name }}" + itemMarks,
 		}
 	}
 	var prompt promptui.Select
@@ -174,58 +239,71 @@ func (s *InteractiveSelector) SelectItems() ([]*SelectorItem, error) {
 	return []*SelectorItem{selectedItem}, nil
 }
 
-func ReleaseSelector(ghClient *api.RESTClient, repo string, version string, interactive bool) (ISelector, error) {
-	response := []struct {
-		Tag_name string
-		Id       int
-	}{}
-	err := ghClient.Get(fmt.Sprintf("repos/%s/releases", repo), &response)
-	if err != nil {
-		return nil, err
+const (
+	GH_INSTALL_CHANNEL_STABLE     = "stable"
+	GH_INSTALL_CHANNEL_PRERELEASE = "prerelease"
+	GH_INSTALL_CHANNEL_ANY        = "any"
+)
+
+// which releases may be selected: stable releases, pre-releases or both, and whether drafts are included
+type ReleasePolicy struct {
+	Channel       string
+	IncludeDrafts bool
+}
+
+func (p ReleasePolicy) Validate() error {
+	switch p.Channel {
+	case "", GH_INSTALL_CHANNEL_STABLE, GH_INSTALL_CHANNEL_PRERELEASE, GH_INSTALL_CHANNEL_ANY:
+		return nil
 	}
+	return fmt.Errorf("unknown release channel '%s', must be one of '%s', '%s' or '%s'", p.Channel,
+		GH_INSTALL_CHANNEL_STABLE, GH_INSTALL_CHANNEL_PRERELEASE, GH_INSTALL_CHANNEL_ANY)
+}
 
-	var items []*SelectorItem
-	for _, val := range response {
-		items = append(items, MakeSelectorItem(val.Tag_name, false, MakeProp("id", val.Id)))
+func (p ReleasePolicy) allowsPrerelease() bool {
+	return p.Channel == GH_INSTALL_CHANNEL_PRERELEASE || p.Channel == GH_INSTALL_CHANNEL_ANY
+}
+
+func (p ReleasePolicy) allows(item *SelectorItem) bool {
+	if item.GetPropBool("draft") && !p.IncludeDrafts {
+		return false
 	}
 
-	if interactive {
-		return &InteractiveSelector{
-			Kind:         "release versions",
-			Items:        items,
-			Prompt:       fmt.Sprintf("Please select %s release tag", repo),
-			LastSelected: 0,
-			Multiple:     false,
-		}, nil
+	switch p.Channel {
+	case GH_INSTALL_CHANNEL_PRERELEASE:
+		return item.GetPropBool("prerelease")
+	case GH_INSTALL_CHANNEL_ANY:
+		return true
 	}
+	return !item.GetPropBool("prerelease")
+}
 
-	versionMatcher := version
-	if versionMatcher == "latest" {
-		response := struct {
-			Tag_name string
-		}{}
+// only 'latest' of stable releases without drafts matches Github's notion of the latest release
+func (p ReleasePolicy) githubLatest() bool {
+	return (p.Channel == "" || p.Channel == GH_INSTALL_CHANNEL_STABLE) && !p.IncludeDrafts
+}
 
-		err := ghClient.Get(fmt.Sprintf("repos/%s/releases/latest", repo), &response)
-		if err != nil {
-			return nil, err
-		}
-		versionMatcher = response.Tag_name
-	}
+type releaseResponse struct {
+	Tag_name     string
+	Id           int
+	Published_at string
+	Prerelease   bool
+	Draft        bool
+}
 
-	return &Selector{
-		Kind:     "release versions",
-		Items:    items,
-		Matcher:  versionMatcher,
-		Multiple: false,
-	}, nil
+func makeReleaseItem(release releaseResponse) *SelectorItem {
+	return MakeSelectorItem(release.Tag_name, false,
+		MakeProp("id", release.Id),
+		MakeProp("publishedAt", release.Published_at),
+		MakeProp("prerelease", release.Prerelease),
+		MakeProp("draft", release.Draft))
 }
 
-func AssetSelector(ghClient *api.RESTClient, repo string,
-	releaseId int, name string, matcher string, interactive bool) (ISelector, error) {
-	var linkRE = regexp.MustCompile(`<([^>]+)>;\s*rel="([^"]+)"`)
-	var items []*SelectorItem
-	page := 1
-	requestPath := fmt.Sprintf("repos/%s/releases/%d/assets", repo, releaseId)
+var linkRE = regexp.MustCompile(`<([^>]+)>;\s*rel="([^"]+)"`)
+
+// fetches all pages of a Github API list, following 'Link: rel="next"' response headers
+func getAllPages[T any](ghClient *api.RESTClient, requestPath string) ([]T, error) {
+	var results []T
 	findNextPage := func(response *http.Response) (string, bool) {
 		for _, m := range linkRE.FindAllStringSubmatch(response.Header.Get("Link"), -1) {
 			if len(m) > 2 && m[2] == "next" {
@@ -242,7 +320,7 @@ func AssetSelector(ghClient *api.RESTClient, repo string,
 		}
 		decoder := json.NewDecoder(response.Body)
 
-		responseData := []struct{ Name string }{}
+		responseData := []T{}
 		err = decoder.Decode(&responseData)
 		if err != nil {
 			return nil, err
@@ -250,16 +328,197 @@ func AssetSelector(ghClient *api.RESTClient, repo string,
 		if err := response.Body.Close(); err != nil {
 			return nil, err
 		}
-
-		for index, val := range responseData {
-			items = append(items, MakeSelectorItem(val.Name, false, MakeProp("id", index)))
-		}
+		results = append(results, responseData...)
 
 		var hasNextPage bool
 		if requestPath, hasNextPage = findNextPage(response); !hasNextPage {
 			break
 		}
-		page++
+	}
+
+	return results, nil
+}
+
+// looks up a single release ('latest' or exact tag) without listing all releases. Returns nil item
+// if there is no such release
+func getRelease(ghClient *api.RESTClient, repo string, version string) (*SelectorItem, error) {
+	releasePath := fmt.Sprintf("repos/%s/releases/latest", repo)
+	if version != "latest" {
+		releasePath = fmt.Sprintf("repos/%s/releases/tags/%s", repo, url.PathEscape(version))
+	}
+
+	response := releaseResponse{}
+	err := ghClient.Get(releasePath, &response)
+	if err != nil {
+		var httpErr *api.HTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return makeReleaseItem(response), nil
+}
+
+// selects release by exact tag, 'latest', semantic version constraint ('~1.2', '^2', '>=1.4 <2', '1.x')
+// or, if versionPattern is not empty, by tag regexp. 'latest' and constraints only consider releases
+// allowed by policy
+func ReleaseSelector(ghClient *api.RESTClient, repo string,
+	version string, versionPattern string, policy ReleasePolicy, interactive bool) (ISelector, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	output.Output().Set("release_versions_channel", policy.Channel)
+
+	isLatest := version == "latest"
+	if !interactive && versionPattern == "" && !semver.IsConstraint(version) && (!isLatest || policy.githubLatest()) {
+		item, err := getRelease(ghClient, repo, version)
+		if err != nil {
+			return nil, err
+		}
+		if item == nil && isLatest {
+			return nil, fmt.Errorf("repository %s has no stable release, use '--prerelease' or '--channel' to install pre-releases", repo)
+		}
+
+		// tags that are not found directly are matched against all releases below
+		if item != nil {
+			return &Selector{
+				Kind:     "release versions",
+				Items:    []*SelectorItem{item},
+				Name:     item.Name,
+				Multiple: false,
+			}, nil
+		}
+	}
+
+	response, err := getAllPages[releaseResponse](ghClient, fmt.Sprintf("repos/%s/releases?per_page=100", repo))
+	if err != nil {
+		return nil, err
+	}
+
+	var items, allowedItems []*SelectorItem
+	for _, val := range response {
+		item := makeReleaseItem(val)
+		if item.GetPropBool("draft") && !policy.IncludeDrafts {
+			continue
+		}
+		items = append(items, item)
+		if policy.allows(item) {
+			allowedItems = append(allowedItems, item)
+		}
+	}
+
+	if interactive {
+		return &InteractiveSelector{
+			Kind:         "release versions",
+			Items:        items,
+			Prompt:       fmt.Sprintf("Please select %s release tag", repo),
+			LastSelected: 0,
+			Multiple:     false,
+		}, nil
+	}
+
+	// tag regexps match any release, like exact tags
+	if versionPattern != "" {
+		return &Selector{
+			Kind:     "release versions",
+			Items:    items,
+			Matcher:  versionPattern,
+			Multiple: false,
+		}, nil
+	}
+
+	versionName := version
+	if isLatest {
+		// releases are listed newest first
+		if len(allowedItems) == 0 {
+			return nil, fmt.Errorf("repository %s has no releases in '%s' channel", repo, policy.Channel)
+		}
+		versionName = allowedItems[0].Name
+	} else if semver.IsConstraint(version) {
+		output.Output().Set("release_versions_constraint", version)
+		versionName, err = newestRelease(items, version, policy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Selector{
+		Kind:     "release versions",
+		Items:    items,
+		Name:     versionName,
+		Multiple: false,
+	}, nil
+}
+
+// tag of the newest release satisfying semantic version constraint. Releases the policy does not allow are only
+// considered if they are pre-releases satisfying a constraint range that mentions a pre-release
+func newestRelease(items []*SelectorItem, constraint string, policy ReleasePolicy) (string, error) {
+	versionConstraint, err := semver.ParseConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+	versionConstraint.IncludePrerelease = policy.allowsPrerelease()
+
+	var newestTag string
+	var newest *semver.Version
+	for _, item := range items {
+		version, err := semver.Parse(item.Name)
+		if err != nil {
+			continue
+		}
+		if policy.allows(item) {
+			if !versionConstraint.Matches(version) {
+				continue
+			}
+		} else if !item.GetPropBool("prerelease") || !versionConstraint.MatchesPrerelease(version) {
+			continue
+		}
+		if newest == nil || version.Compare(newest) > 0 {
+			newestTag, newest = item.Name, version
+		}
+	}
+
+	if newest == nil {
+		return "", fmt.Errorf("no release versions matching constraint '%s' found", constraint)
+	}
+	return newestTag, nil
+}
+
+type assetResponse struct {
+	Name                 string
+	Digest               string
+	Size                 int
+	Content_type         string
+	Browser_download_url string
+}
+
+func listReleaseAssets(ghClient *api.RESTClient, repo string, releaseId int) ([]*SelectorItem, error) {
+	response, err := getAllPages[assetResponse](ghClient,
+		fmt.Sprintf("repos/%s/releases/%d/assets?per_page=100", repo, releaseId))
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*SelectorItem
+	for index, val := range response {
+		items = append(items, MakeSelectorItem(val.Name, false,
+			MakeProp("id", index),
+			MakeProp("digest", val.Digest),
+			MakeProp("size", val.Size),
+			MakeProp("contentType", val.Content_type),
+			MakeProp("downloadUrl", val.Browser_download_url)))
+	}
+
+	return items, nil
+}
+
+// selects release asset by name, by matcher regexp or, if both are empty, by best score for target platform
+func AssetSelector(ghClient *api.RESTClient, repo string,
+	releaseId int, name string, matcher string, target platform.Platform, interactive bool) (ISelector, error) {
+	items, err := listReleaseAssets(ghClient, repo, releaseId)
+	if err != nil {
+		return nil, err
 	}
 
 	if interactive {
@@ -272,6 +531,14 @@ func AssetSelector(ghClient *api.RESTClient, repo string,
 		}, nil
 	}
 
+	if name == "" && matcher == "" {
+		return &ScoringSelector{
+			Kind:   "release assets",
+			Items:  items,
+			Target: target,
+		}, nil
+	}
+
 	return &Selector{
 		Kind:     "release assets",
 		Items:    items,
@@ -281,7 +548,57 @@ func AssetSelector(ghClient *api.RESTClient, repo string,
 	}, nil
 }
 
-func BinarySelector(downloadPath string, name string, matcher string, interactive bool) (ISelector, error) {
+// selects all release assets that may hold checksums for other assets of the release
+func ChecksumSelector(ghClient *api.RESTClient, repo string, releaseId int, matcher string) (ISelector, error) {
+	items, err := listReleaseAssets(ghClient, repo, releaseId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Selector{
+		Kind:     "release checksums",
+		Items:    items,
+		Matcher:  matcher,
+		Multiple: true,
+	}, nil
+}
+
+// selects each of names exactly once, unless multiple is set
+func (s *Selector) selectNamedItems() ([]*SelectorItem, error) {
+	output.Output().Set(fmt.Sprintf("%s_%s", strings.ReplaceAll(s.Kind, " ", "_"), "names"), s.Names)
+	output.Output().Set(fmt.Sprintf("%s_%s", strings.ReplaceAll(s.Kind, " ", "_"), "multiple"), s.Multiple)
+
+	var selectedItems []*SelectorItem
+	matches := make([]string, 0, len(s.Names))
+	for _, name := range s.Names {
+		var named []*SelectorItem
+		for _, item := range s.Items {
+			if strings.EqualFold(name, item.Name) && !item.Selected {
+				item.Selected = true
+				named = append(named, item)
+			}
+		}
+
+		if len(named) == 0 {
+			return nil, fmt.Errorf("no %s named '%s' found", s.Kind, name)
+		}
+		if !s.Multiple && len(named) > 1 {
+			return nil, fmt.Errorf("more than one item '%s' found for %s", name, s.Kind)
+		}
+		for _, item := range named {
+			matches = append(matches, item.Name)
+		}
+		selectedItems = append(selectedItems, named...)
+	}
+	output.Output().Set(fmt.Sprintf("%s_%s", strings.ReplaceAll(s.Kind, " ", "_"), "matches"), matches)
+
+	return selectedItems, nil
+}
+
+// selects binaries of the downloaded asset: archive files named one of names or matching matcher, or the
+// downloaded asset itself if it is not an archive. More than one match is an error unless multiple is set
+func BinarySelector(downloadPath string, names []string, matcher string, multiple bool,
+	interactive bool) (ISelector, error) {
 	inputStream, err := os.Open(downloadPath)
 	if err != nil {
 		return nil, err
@@ -312,7 +629,7 @@ func BinarySelector(downloadPath string, name string, matcher string, interactiv
 			return &Selector{
 				Kind:     "release asset binaries",
 				Items:    items,
-				Name:     name,
+				Names:    names,
 				Matcher:  path.Base(downloadPath),
 				Multiple: false,
 			}, nil
@@ -331,6 +648,10 @@ func BinarySelector(downloadPath string, name string, matcher string, interactiv
 		}
 
 		if !d.IsDir() {
+			var mode fs.FileMode
+			if info, err := d.Info(); err == nil {
+				mode = info.Mode()
+			}
 			items = append(items,
 				MakeSelectorItem(
 					d.Name(),
@@ -338,6 +659,7 @@ func BinarySelector(downloadPath string, name string, matcher string, interactiv
 					MakeProp("archive", true),
 					MakeProp("path", fsPath),
 					MakeProp("fs", fileSystem),
+					MakeProp("mode", mode),
 					MakeProp("id", 0)))
 		}
 		return nil
@@ -359,8 +681,8 @@ func BinarySelector(downloadPath string, name string, matcher string, interactiv
 	return &Selector{
 		Kind:     "release asset binaries",
 		Items:    items,
-		Name:     name,
+		Names:    names,
 		Matcher:  matcher,
-		Multiple: false,
+		Multiple: multiple,
 	}, nil
 }
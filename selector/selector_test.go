@@ -0,0 +1,44 @@
+package selector
+
+import "testing"
+
+func TestNewestRelease(t *testing.T) {
+	items := []*SelectorItem{
+		makeReleaseItem(releaseResponse{Tag_name: "v2.0.0-rc.1", Prerelease: true}),
+		makeReleaseItem(releaseResponse{Tag_name: "v1.5.0", Prerelease: false}),
+		makeReleaseItem(releaseResponse{Tag_name: "v1.4.2", Prerelease: false}),
+		makeReleaseItem(releaseResponse{Tag_name: "v1.6.0-beta", Prerelease: true}),
+		makeReleaseItem(releaseResponse{Tag_name: "nightly", Prerelease: true}),
+	}
+	stable := ReleasePolicy{Channel: GH_INSTALL_CHANNEL_STABLE}
+	anyChannel := ReleasePolicy{Channel: GH_INSTALL_CHANNEL_ANY}
+	prerelease := ReleasePolicy{Channel: GH_INSTALL_CHANNEL_PRERELEASE}
+
+	tests := []struct {
+		constraint string
+		policy     ReleasePolicy
+		expected   string
+	}{
+		{"^1", stable, "v1.5.0"},
+		{"~1.4", stable, "v1.4.2"},
+		{"^1", anyChannel, "v1.6.0-beta"},
+		{"^1", prerelease, "v1.6.0-beta"},
+		{"^2.0.0-rc.0", stable, "v2.0.0-rc.1"},
+		{">=1.6.0-alpha <1.7", stable, "v1.6.0-beta"},
+		{"^2", stable, ""},
+	}
+
+	for _, test := range tests {
+		tag, err := newestRelease(items, test.constraint, test.policy)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("%q (%s) selected %s, expected no release", test.constraint, test.policy.Channel, tag)
+			}
+			continue
+		}
+		if err != nil || tag != test.expected {
+			t.Errorf("%q (%s) selected %q (%v), expected %s", test.constraint, test.policy.Channel, tag, err,
+				test.expected)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// semantic version ending a tag, after a prefix such as 'v', 'release-', 'tool/v' or 'tool2-v'
+	versionRE = regexp.MustCompile(`^(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+	// version pattern of a constraint: partial versions and 'x' / '*' wildcards are allowed
+	partialRE  = regexp.MustCompile(`^v?(\d+|[xX*])(?:\.(\d+|[xX*]))?(?:\.(\d+|[xX*]))?(?:-([0-9A-Za-z.-]+))?$`)
+	operatorRE = regexp.MustCompile(`^(>=|<=|>|<|=|~|\^)?\s*(.+)$`)
+	// operator separated from its version: '>= 1.4'
+	spacedOperatorRE = regexp.MustCompile(`(>=|<=|>|<|=|~|\^)\s+`)
+	// operators, range separators or 'x' / '*' wildcards distinguish constraints from exact tags
+	constraintRE = regexp.MustCompile(`[<>=~^*|, ]|(?:^|\.)[xX](?:\.|$)`)
+)
+
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// parses semantic version from a release tag such as 'v1.2.3', '1.2', 'release-1.2.3-rc.1'
+func Parse(tag string) (*Version, error) {
+	// versions start at numbers that do not continue a number. The first dotted version wins, so that numbers in
+	// the prefix ('tool2-v1.2.3') or pre-release ('1.2.3-rc-2') are not taken for the version
+	trimmed := strings.TrimSpace(tag)
+	var m []string
+	for index := range trimmed {
+		if !isDigit(trimmed[index]) || index > 0 && (isDigit(trimmed[index-1]) || trimmed[index-1] == '.') {
+			continue
+		}
+		candidate := versionRE.FindStringSubmatch(trimmed[index:])
+		if candidate == nil {
+			continue
+		}
+		if m == nil {
+			m = candidate
+		}
+		if candidate[2] != "" {
+			m = candidate
+			break
+		}
+	}
+	if m == nil {
+		return nil, fmt.Errorf("'%s' is not a semantic version", tag)
+	}
+
+	version := &Version{Prerelease: m[4]}
+	version.Major, _ = strconv.Atoi(m[1])
+	version.Minor, _ = strconv.Atoi(m[2])
+	version.Patch, _ = strconv.Atoi(m[3])
+
+	return version, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (v *Version) String() string {
+	if v.Prerelease != "" {
+		return fmt.Sprintf("%d.%d.%d-%s", v.Major, v.Minor, v.Patch, v.Prerelease)
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+func compareInt(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compares pre-release identifiers according to semver precedence rules
+func comparePrerelease(a string, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareInt(aNum, bNum); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(aParts[i], bParts[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return compareInt(len(aParts), len(bParts))
+}
+
+// returns -1, 0 or 1 if v is lower than, equal to or greater than other
+func (v *Version) Compare(other *Version) int {
+	if c := compareInt(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+type comparator struct {
+	operator string
+	version  *Version
+}
+
+func (c comparator) matches(v *Version) bool {
+	compared := v.Compare(c.version)
+	switch c.operator {
+	case ">":
+		return compared > 0
+	case ">=":
+		return compared >= 0
+	case "<":
+		return compared < 0
+	case "<=":
+		return compared <= 0
+	}
+	return compared == 0
+}
+
+// set of comparators that must all match
+type versionRange struct {
+	comparators []comparator
+	prerelease  bool
+}
+
+func (r versionRange) matches(v *Version, includePrerelease bool) bool {
+	// unless included, pre-releases only satisfy ranges that explicitly mention a pre-release
+	if v.Prerelease != "" && !r.prerelease && !includePrerelease {
+		return false
+	}
+
+	for _, c := range r.comparators {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// version constraint such as '~1.2', '^2', '>=1.4 <2', '1.x' or '>=1 <1.5 || ^2'
+type Constraint struct {
+	ranges []versionRange
+	// pre-release versions satisfy ranges that do not mention a pre-release
+	IncludePrerelease bool
+}
+
+// expands constraint term (operator and possibly partial version) into comparators
+func parseTerm(term string) ([]comparator, bool, error) {
+	m := operatorRE.FindStringSubmatch(term)
+	if m == nil {
+		return nil, false, fmt.Errorf("invalid constraint '%s'", term)
+	}
+	operator := m[1]
+
+	p := partialRE.FindStringSubmatch(m[2])
+	if p == nil {
+		return nil, false, fmt.Errorf("invalid version '%s' in constraint '%s'", m[2], term)
+	}
+
+	// number of leading version parts that are specified
+	specified := 0
+	numbers := make([]int, 3)
+	for i := 1; i <= 3; i++ {
+		if p[i] == "" || strings.ContainsAny(p[i], "xX*") {
+			break
+		}
+		numbers[i-1], _ = strconv.Atoi(p[i])
+		specified++
+	}
+	prerelease := p[4]
+	if specified < 3 {
+		prerelease = ""
+	}
+
+	lower := &Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2], Prerelease: prerelease}
+	// first version outside of partial version: 1.2 -> 1.3.0, 1 -> 2.0.0
+	upper := func(parts int) *Version {
+		switch parts {
+		case 1:
+			return &Version{Major: numbers[0] + 1, Prerelease: "0"}
+		case 2:
+			return &Version{Major: numbers[0], Minor: numbers[1] + 1, Prerelease: "0"}
+		}
+		return &Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2] + 1, Prerelease: "0"}
+	}
+	hasPrerelease := prerelease != ""
+
+	if specified == 0 {
+		switch operator {
+		case "", "=", ">=", "<=", "~", "^":
+			return nil, hasPrerelease, nil
+		}
+		return nil, false, fmt.Errorf("invalid constraint '%s'", term)
+	}
+
+	switch operator {
+	case "", "=":
+		if specified == 3 {
+			return []comparator{{"=", lower}}, hasPrerelease, nil
+		}
+		return []comparator{{">=", lower}, {"<", upper(specified)}}, hasPrerelease, nil
+	case ">":
+		if specified == 3 {
+			return []comparator{{">", lower}}, hasPrerelease, nil
+		}
+		return []comparator{{">=", upper(specified)}}, hasPrerelease, nil
+	case ">=":
+		return []comparator{{">=", lower}}, hasPrerelease, nil
+	case "<":
+		// '<1.2' must also exclude 1.2.0 pre-releases
+		lower.Prerelease = firstPrerelease(prerelease)
+		return []comparator{{"<", lower}}, hasPrerelease, nil
+	case "<=":
+		if specified == 3 {
+			return []comparator{{"<=", lower}}, hasPrerelease, nil
+		}
+		return []comparator{{"<", upper(specified)}}, hasPrerelease, nil
+	case "~":
+		parts := 2
+		if specified == 1 {
+			parts = 1
+		}
+		return []comparator{{">=", lower}, {"<", upper(parts)}}, hasPrerelease, nil
+	case "^":
+		parts := 1
+		switch {
+		case numbers[0] == 0 && specified >= 2 && numbers[1] == 0 && specified == 3:
+			parts = 3
+		case numbers[0] == 0 && specified >= 2:
+			parts = 2
+		}
+		return []comparator{{">=", lower}, {"<", upper(parts)}}, hasPrerelease, nil
+	}
+
+	return nil, false, fmt.Errorf("invalid constraint '%s'", term)
+}
+
+// lowest pre-release of a release
+func firstPrerelease(prerelease string) string {
+	if prerelease != "" {
+		return prerelease
+	}
+	return "0"
+}
+
+// reports whether tag is a version constraint rather than an exact release tag
+func IsConstraint(tag string) bool {
+	return constraintRE.MatchString(strings.TrimSpace(tag))
+}
+
+// parses version constraint. Range terms are separated by spaces or commas, ranges by '||'
+func ParseConstraint(constraint string) (*Constraint, error) {
+	parsed := &Constraint{}
+	for _, rangeStr := range strings.Split(constraint, "||") {
+		rangeStr = spacedOperatorRE.ReplaceAllString(rangeStr, "$1")
+		terms := strings.FieldsFunc(rangeStr, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+		if len(terms) == 0 {
+			return nil, fmt.Errorf("invalid constraint '%s'", constraint)
+		}
+
+		versionRange := versionRange{}
+		for _, term := range terms {
+			comparators, prerelease, err := parseTerm(term)
+			if err != nil {
+				return nil, err
+			}
+			versionRange.comparators = append(versionRange.comparators, comparators...)
+			versionRange.prerelease = versionRange.prerelease || prerelease
+		}
+		parsed.ranges = append(parsed.ranges, versionRange)
+	}
+
+	return parsed, nil
+}
+
+// reports whether v satisfies a range of the constraint that mentions a pre-release, whether or not pre-releases
+// are included
+func (c *Constraint) MatchesPrerelease(v *Version) bool {
+	for _, r := range c.ranges {
+		if r.prerelease && r.matches(v, true) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Constraint) Matches(v *Version) bool {
+	for _, r := range c.ranges {
+		if r.matches(v, c.IncludePrerelease) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package semver
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected string
+		valid    bool
+	}{
+		{"v1.2.3", "1.2.3", true},
+		{"1.2", "1.2.0", true},
+		{"v2", "2.0.0", true},
+		{"release-1.2.3-rc.1", "1.2.3-rc.1", true},
+		{"tool/v1.4.0", "1.4.0", true},
+		{"v1.2.3+build.5", "1.2.3", true},
+		{"tool2-v1.2.3", "1.2.3", true},
+		{"tool-2-v1.2.3", "1.2.3", true},
+		{"go1.21.0", "1.21.0", true},
+		{"1.0.0-rc-2", "1.0.0-rc-2", true},
+		{"v2-rc1", "2.0.0-rc1", true},
+		{"nightly", "", false},
+		{"", "", false},
+	}
+
+	for _, test := range tests {
+		version, err := Parse(test.tag)
+		if (err == nil) != test.valid {
+			t.Errorf("Parse(%q) error = %v, expected valid %v", test.tag, err, test.valid)
+			continue
+		}
+		if test.valid && version.String() != test.expected {
+			t.Errorf("Parse(%q) = %s, expected %s", test.tag, version, test.expected)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-rc.1", "1.0.0-beta", 1},
+	}
+
+	for _, test := range tests {
+		a, _ := Parse(test.a)
+		b, _ := Parse(test.b)
+		if compared := a.Compare(b); compared != test.expected {
+			t.Errorf("%s compared to %s = %d, expected %d", test.a, test.b, compared, test.expected)
+		}
+	}
+}
+
+func TestIsConstraint(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected bool
+	}{
+		{"v1.2.3", false},
+		{"1.2", false},
+		{"nightly", false},
+		{"tool/v1.4.0", false},
+		{"~1.2", true},
+		{"^2", true},
+		{">=1.4 <2", true},
+		{"1.x", true},
+		{"1.2.*", true},
+		{"1 || 2", true},
+		{"x", true},
+	}
+
+	for _, test := range tests {
+		if isConstraint := IsConstraint(test.tag); isConstraint != test.expected {
+			t.Errorf("IsConstraint(%q) = %v, expected %v", test.tag, isConstraint, test.expected)
+		}
+	}
+}
+
+func TestConstraintMatches(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		// exact and partial versions
+		{"1.2.3", "1.2.3", true},
+		{"1.2.3", "1.2.4", false},
+		{"=1.2", "1.2.9", true},
+		{"1.2", "1.3.0", false},
+		{"1", "1.99.0", true},
+		{"1", "2.0.0", false},
+		// wildcards
+		{"1.x", "1.5.0", true},
+		{"1.x", "2.0.0", false},
+		{"1.2.*", "1.2.7", true},
+		{"1.2.*", "1.3.0", false},
+		{"*", "3.1.4", true},
+		{"x", "0.0.1", true},
+		// comparison operators
+		{">1.2.3", "1.2.4", true},
+		{">1.2.3", "1.2.3", false},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{">=1.4", "1.4.0", true},
+		{">=1.4", "1.3.9", false},
+		{"<2", "1.99.99", true},
+		{"<2", "2.0.0", false},
+		{"<=1.2", "1.2.9", true},
+		{"<=1.2", "1.3.0", false},
+		{"<=1.2.3", "1.2.3", true},
+		// tilde
+		{"~1.2", "1.2.9", true},
+		{"~1.2", "1.3.0", false},
+		{"~1.2.3", "1.2.2", false},
+		{"~1.2.3", "1.2.5", true},
+		{"~1", "1.9.0", true},
+		{"~1", "2.0.0", false},
+		// caret
+		{"^2", "2.9.9", true},
+		{"^2", "3.0.0", false},
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "1.2.2", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.3", true},
+		{"^0.0.3", "0.0.4", false},
+		// ranges
+		{">=1.4 <2", "1.5.0", true},
+		{">=1.4 <2", "2.0.0", false},
+		{">= 1.4, < 2", "1.4.0", true},
+		{">=1 <1.5 || ^2", "1.6.0", false},
+		{">=1 <1.5 || ^2", "2.1.0", true},
+		{">=1 <1.5 || ^2", "1.4.9", true},
+		// pre-releases only satisfy ranges that mention a pre-release
+		{"^1.2", "1.3.0-rc.1", false},
+		{"<2", "2.0.0-rc.1", false},
+		{">=1.2.3-rc.1", "1.2.3-rc.2", true},
+		{">=1.2.3-rc.1", "1.2.3", true},
+		{"~1.2.3-beta", "1.2.3-rc.1", true},
+	}
+
+	for _, test := range tests {
+		constraint, err := ParseConstraint(test.constraint)
+		if err != nil {
+			t.Errorf("ParseConstraint(%q) failed: %v", test.constraint, err)
+			continue
+		}
+		version, err := Parse(test.version)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", test.version, err)
+		}
+		if matches := constraint.Matches(version); matches != test.expected {
+			t.Errorf("%q matches %s = %v, expected %v", test.constraint, test.version, matches, test.expected)
+		}
+	}
+}
+
+func TestConstraintIncludePrerelease(t *testing.T) {
+	constraint, err := ParseConstraint("^1.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, _ := Parse("1.3.0-rc.1")
+	if constraint.Matches(version) {
+		t.Errorf("^1.2 matches %s without included pre-releases", version)
+	}
+	constraint.IncludePrerelease = true
+	if !constraint.Matches(version) {
+		t.Errorf("^1.2 does not match %s with included pre-releases", version)
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	for _, constraint := range []string{"", "||", ">", ">foo", "~>1.2", "1.2.3.4", ">x", "1 || "} {
+		if _, err := ParseConstraint(constraint); err == nil {
+			t.Errorf("ParseConstraint(%q) succeeded, expected an error", constraint)
+		}
+	}
+}
+
+func TestConstraintMatchesPrerelease(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{"^2.0.0-rc.0", "2.0.0-rc.1", true},
+		{"^2.0.0-rc.0", "2.0.0", true},
+		{"^2", "2.0.0-rc.1", false},
+		{"^2", "2.1.0", false},
+		{">=1.4 <2 || >=2.0.0-beta <2.0.0-rc.5", "2.0.0-rc.1", true},
+		{">=1.4 <2 || >=2.0.0-beta <2.0.0-rc.5", "1.5.0", false},
+	}
+
+	for _, test := range tests {
+		constraint, err := ParseConstraint(test.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q) failed: %v", test.constraint, err)
+		}
+		version, _ := Parse(test.version)
+		if matches := constraint.MatchesPrerelease(version); matches != test.expected {
+			t.Errorf("%q matches pre-release %s = %v, expected %v", test.constraint, test.version, matches,
+				test.expected)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	GH_INSTALL_DATA_DIR_NAME  = "gh-install"
+	GH_INSTALL_STORE_DIR_NAME = "store"
+	// receipt of the version installed to a store version directory
+	GH_INSTALL_RECEIPT_NAME = "gh-install-receipt.json"
+)
+
+// $XDG_DATA_HOME, ~/.local/share if unset
+func DataHome() string {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dataHome = path.Join(homeDir, ".local", "share")
+	}
+	return dataHome
+}
+
+// gh-install data directory under $XDG_DATA_HOME
+func DataDir() string {
+	dataHome := DataHome()
+	if dataHome == "" {
+		return ""
+	}
+	return path.Join(dataHome, GH_INSTALL_DATA_DIR_NAME)
+}
+
+// directory holding all stored versions of repo
+func RepoDir(repo string) string {
+	return path.Join(DataDir(), GH_INSTALL_STORE_DIR_NAME, strings.ToLower(repo))
+}
+
+// directory holding files of release tag of repo. Tags are escaped, so that tags such as 'tool/v1.2' do not
+// create nested directories
+func VersionDir(repo string, tag string) string {
+	return path.Join(RepoDir(repo), url.PathEscape(tag))
+}
+
+// tags of all stored versions of repo. Only directories holding a receipt are versions
+func Versions(repo string) ([]string, error) {
+	entries, err := os.ReadDir(RepoDir(repo))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var tags []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(path.Join(RepoDir(repo), entry.Name(), GH_INSTALL_RECEIPT_NAME)); err != nil {
+			continue
+		}
+		tag, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func RemoveVersion(repo string, tag string) error {
+	return os.RemoveAll(VersionDir(repo, tag))
+}
+
+func RemoveRepo(repo string) error {
+	return os.RemoveAll(RepoDir(repo))
+}